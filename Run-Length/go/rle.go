@@ -0,0 +1,242 @@
+package rle
+
+import (
+    "encoding/binary"
+    "fmt"
+    "io"
+    "os"
+
+    "bufio"
+
+    "github.com/LessUp/Encoding/codec"
+)
+
+// Run-Length 编码实现。
+// 格式：重复写入 4 字节小端无符号整数 count + 1 字节 value，直到输入结束。
+// 三种语言（C++/Go/Rust）都使用相同的格式，方便交叉解码与基准测试。
+
+// Encode 对 r 中的全部数据执行 Run-Length 编码，写入 w。
+func Encode(w io.Writer, r io.Reader) error {
+    br := bufio.NewReader(r)
+    bw := bufio.NewWriter(w)
+
+    first, err := br.ReadByte()
+    if err == io.EOF {
+        // 空输入，编码结果也是空输出。
+        return bw.Flush()
+    }
+    if err != nil {
+        return fmt.Errorf("读取输入失败: %w", err)
+    }
+
+    current := first
+    var count uint32 = 1
+
+    for {
+        b, err := br.ReadByte()
+        if err == io.EOF {
+            // 写出最后一段
+            if err := writeRun(bw, count, current); err != nil {
+                return fmt.Errorf("写入 RLE 数据失败: %w", err)
+            }
+            break
+        }
+        if err != nil {
+            return fmt.Errorf("读取输入失败: %w", err)
+        }
+
+        if b == current && count < ^uint32(0) {
+            count++
+        } else {
+            if err := writeRun(bw, count, current); err != nil {
+                return fmt.Errorf("写入 RLE 数据失败: %w", err)
+            }
+            current = b
+            count = 1
+        }
+    }
+
+    return bw.Flush()
+}
+
+// writeRun 将单个 (count, value) 段写入输出流。
+func writeRun(w *bufio.Writer, count uint32, value byte) error {
+    // 写入 4 字节小端 count
+    if err := binary.Write(w, binary.LittleEndian, count); err != nil {
+        return err
+    }
+    // 写入 1 字节 value
+    if err := w.WriteByte(value); err != nil {
+        return err
+    }
+    return nil
+}
+
+// AppendEncoded 对 src 执行 Run-Length 编码，并将结果追加到 dst 后返回。
+// 最坏情况下每个输入字节各自成一段（5 字节），因此按 5*len(src) 一次性扩容，
+// 避免 dst 在追加过程中反复重新分配。
+func AppendEncoded(dst, src []byte) []byte {
+    dst = grow(dst, 5*len(src))
+    if len(src) == 0 {
+        return dst
+    }
+
+    current := src[0]
+    var count uint32 = 1
+    for _, b := range src[1:] {
+        if b == current && count < ^uint32(0) {
+            count++
+            continue
+        }
+        dst = appendRun(dst, count, current)
+        current = b
+        count = 1
+    }
+    return appendRun(dst, count, current)
+}
+
+// appendRun 将单个 (count, value) 段追加到 dst 后返回。
+func appendRun(dst []byte, count uint32, value byte) []byte {
+    dst = append(dst, byte(count), byte(count>>8), byte(count>>16), byte(count>>24))
+    return append(dst, value)
+}
+
+// grow 在需要时为 dst 扩容出至少 extra 字节的剩余容量。
+func grow(dst []byte, extra int) []byte {
+    if cap(dst)-len(dst) >= extra {
+        return dst
+    }
+    grown := make([]byte, len(dst), len(dst)+extra)
+    copy(grown, dst)
+    return grown
+}
+
+// DecodeInto 将 src 中的 RLE 编码数据解码后追加到 dst 并返回。
+func DecodeInto(dst, src []byte) ([]byte, error) {
+    pos := 0
+    for pos < len(src) {
+        if pos+5 > len(src) {
+            return dst, fmt.Errorf("RLE 数据截断：无法读取完整的段")
+        }
+        count := binary.LittleEndian.Uint32(src[pos:])
+        if count == 0 {
+            return dst, fmt.Errorf("RLE 数据非法：count 不应为 0")
+        }
+        value := src[pos+4]
+        pos += 5
+
+        dst = grow(dst, int(count))
+        for i := uint32(0); i < count; i++ {
+            dst = append(dst, value)
+        }
+    }
+    return dst, nil
+}
+
+// Decode 将 r 中的 RLE 编码数据解码为原始字节序列，写入 w。
+func Decode(w io.Writer, r io.Reader) error {
+    br := bufio.NewReader(r)
+    bw := bufio.NewWriter(w)
+
+    buf := make([]byte, 4096)
+
+    for {
+        var count uint32
+        if err := binary.Read(br, binary.LittleEndian, &count); err != nil {
+            if err == io.EOF {
+                // 正常 EOF
+                break
+            }
+            if err == io.ErrUnexpectedEOF {
+                return fmt.Errorf("RLE 数据截断：无法读取完整的 count 字段")
+            }
+            return fmt.Errorf("读取 count 失败: %w", err)
+        }
+        if count == 0 {
+            return fmt.Errorf("RLE 数据非法：count 不应为 0")
+        }
+
+        value, err := br.ReadByte()
+        if err != nil {
+            if err == io.EOF {
+                return fmt.Errorf("RLE 数据截断：缺少 value 字节")
+            }
+            return fmt.Errorf("读取 value 失败: %w", err)
+        }
+
+        // 将 (count, value) 展开写回输出
+        for count > 0 {
+            chunk := int(count)
+            if chunk > len(buf) {
+                chunk = len(buf)
+            }
+            for i := 0; i < chunk; i++ {
+                buf[i] = value
+            }
+            if _, err := bw.Write(buf[:chunk]); err != nil {
+                return fmt.Errorf("写入解码数据失败: %w", err)
+            }
+            count -= uint32(chunk)
+        }
+    }
+
+    return bw.Flush()
+}
+
+// RLEEncodeFile 对整个文件执行 Run-Length 编码。
+func RLEEncodeFile(inputPath, outputPath string) {
+    if err := encodeFile(inputPath, outputPath); err != nil {
+        fmt.Fprintln(os.Stderr, err)
+    }
+}
+
+// RLEDecodeFile 将 RLE 编码文件解码为原始字节序列。
+func RLEDecodeFile(inputPath, outputPath string) {
+    if err := decodeFile(inputPath, outputPath); err != nil {
+        fmt.Fprintln(os.Stderr, err)
+    }
+}
+
+func encodeFile(inputPath, outputPath string) error {
+    in, err := os.Open(inputPath)
+    if err != nil {
+        return fmt.Errorf("无法打开输入文件用于读取: %s: %w", inputPath, err)
+    }
+    defer in.Close()
+
+    out, err := os.Create(outputPath)
+    if err != nil {
+        return fmt.Errorf("无法打开输出文件用于写入: %s: %w", outputPath, err)
+    }
+    defer out.Close()
+
+    return Encode(out, in)
+}
+
+func decodeFile(inputPath, outputPath string) error {
+    in, err := os.Open(inputPath)
+    if err != nil {
+        return fmt.Errorf("无法打开输入文件用于读取: %s: %w", inputPath, err)
+    }
+    defer in.Close()
+
+    out, err := os.Create(outputPath)
+    if err != nil {
+        return fmt.Errorf("无法打开输出文件用于写入: %s: %w", outputPath, err)
+    }
+    defer out.Close()
+
+    return Decode(out, in)
+}
+
+// Codec adapts this package's Encode/Decode to the codec.Codec interface
+// used by package container and the encoding CLI.
+type Codec struct{}
+
+func (Codec) Encode(w io.Writer, r io.Reader) error { return Encode(w, r) }
+func (Codec) Decode(w io.Writer, r io.Reader) error { return Decode(w, r) }
+func (Codec) Name() string                          { return "rle" }
+
+func init() {
+    codec.Register(codec.IDRLE, Codec{})
+}
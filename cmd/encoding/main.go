@@ -0,0 +1,117 @@
+// Command encoding is the unified CLI for every codec in this repository.
+// It wraps output in a self-describing container frame by default so
+// `encoding decode` never needs to be told which algorithm produced a file;
+// pass --raw to read or write the per-algorithm format instead, for
+// interop with the C++/Rust siblings.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/LessUp/Encoding/codec"
+	"github.com/LessUp/Encoding/container"
+
+	_ "github.com/LessUp/Encoding/Run-Length/go"
+	_ "github.com/LessUp/Encoding/fse/go"
+	_ "github.com/LessUp/Encoding/huffman/go"
+	_ "github.com/LessUp/Encoding/lzhuffman"
+	_ "github.com/LessUp/Encoding/lzrange"
+	_ "github.com/LessUp/Encoding/range/go"
+)
+
+func usage() {
+	fmt.Fprintf(os.Stderr, `Usage:
+  %s encode --codec=NAME [--raw] [--no-checksum] input output
+  %s decode [--codec=NAME] [--raw] input output
+
+NAME is one of: rle, huffman, range, fse, lzhuffman, lzrange
+`, os.Args[0], os.Args[0])
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(1)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "encode":
+		err = runEncode(os.Args[2:])
+	case "decode":
+		err = runDecode(os.Args[2:])
+	default:
+		usage()
+		os.Exit(1)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func runEncode(args []string) error {
+	fs := flag.NewFlagSet("encode", flag.ExitOnError)
+	name := fs.String("codec", "", "codec name: rle, huffman, range, fse, lzhuffman, lzrange")
+	raw := fs.Bool("raw", false, "write the per-algorithm format with no container frame")
+	noChecksum := fs.Bool("no-checksum", false, "omit the container's CRC32 trailer (ignored with --raw)")
+	fs.Parse(args)
+	if fs.NArg() != 2 {
+		return fmt.Errorf("encode: expected input and output paths")
+	}
+	input, output := fs.Arg(0), fs.Arg(1)
+
+	id, c, ok := codec.LookupByName(*name)
+	if !ok {
+		return fmt.Errorf("encode: unknown codec %q", *name)
+	}
+
+	in, err := os.Open(input)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	if *raw {
+		return c.Encode(out, in)
+	}
+	return container.Encode(out, in, id, c, container.Options{Checksum: !*noChecksum})
+}
+
+func runDecode(args []string) error {
+	fs := flag.NewFlagSet("decode", flag.ExitOnError)
+	name := fs.String("codec", "", "codec name, required with --raw")
+	raw := fs.Bool("raw", false, "read the per-algorithm format with no container frame")
+	fs.Parse(args)
+	if fs.NArg() != 2 {
+		return fmt.Errorf("decode: expected input and output paths")
+	}
+	input, output := fs.Arg(0), fs.Arg(1)
+
+	in, err := os.Open(input)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	if !*raw {
+		return container.Decode(out, in)
+	}
+	_, c, ok := codec.LookupByName(*name)
+	if !ok {
+		return fmt.Errorf("decode: --raw requires a known --codec, got %q", *name)
+	}
+	return c.Decode(out, in)
+}
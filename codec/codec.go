@@ -0,0 +1,72 @@
+// Package codec defines the interface every compression algorithm in this
+// repository implements, plus a registry keyed by the 1-byte id that package
+// container writes into its frame header.
+package codec
+
+import (
+	"fmt"
+	"io"
+)
+
+// Codec compresses and decompresses a full stream. Implementations read all
+// of r before returning, the same one-shot shape as rangecoder.Encode.
+type Codec interface {
+	// Encode reads all of r and writes its compressed form to w.
+	Encode(w io.Writer, r io.Reader) error
+	// Decode reads all of r and writes its decompressed form to w.
+	Decode(w io.Writer, r io.Reader) error
+	// Name identifies the codec in CLI flags and container frame errors.
+	Name() string
+}
+
+// Ids identify a codec in a container frame header. New codecs append to
+// this list; ids already assigned must never change, since they are part of
+// the on-disk frame format.
+const (
+	IDRLE       byte = 1
+	IDHuffman   byte = 2
+	IDRange     byte = 3
+	IDFSE       byte = 4
+	IDLZHuffman byte = 5
+	IDLZRange   byte = 6
+)
+
+type entry struct {
+	id byte
+	c  Codec
+}
+
+var (
+	byID   = make(map[byte]Codec)
+	byName = make(map[string]entry)
+)
+
+// Register makes c available to package container and the encoding CLI
+// under id. It panics if id or c.Name() is already registered, mirroring
+// image.RegisterFormat; codecs register themselves from an init func, so a
+// duplicate means two packages were compiled in with the same id.
+func Register(id byte, c Codec) {
+	if _, exists := byID[id]; exists {
+		panic(fmt.Sprintf("codec: Register called twice for id %d", id))
+	}
+	if _, exists := byName[c.Name()]; exists {
+		panic(fmt.Sprintf("codec: Register called twice for name %q", c.Name()))
+	}
+	byID[id] = c
+	byName[c.Name()] = entry{id: id, c: c}
+}
+
+// Lookup returns the codec registered under id.
+func Lookup(id byte) (Codec, bool) {
+	c, ok := byID[id]
+	return c, ok
+}
+
+// LookupByName returns the codec registered under name along with its id.
+func LookupByName(name string) (id byte, c Codec, ok bool) {
+	e, found := byName[name]
+	if !found {
+		return 0, nil, false
+	}
+	return e.id, e.c, true
+}
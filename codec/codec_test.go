@@ -0,0 +1,73 @@
+package codec
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+// echoCodec is a minimal Codec used only to exercise the registry, without
+// pulling in any real algorithm package (which would self-register under a
+// fixed id/name via init and could collide with another test file's use).
+type echoCodec struct{ name string }
+
+func (echoCodec) Encode(w io.Writer, r io.Reader) error { _, err := io.Copy(w, r); return err }
+func (echoCodec) Decode(w io.Writer, r io.Reader) error { _, err := io.Copy(w, r); return err }
+func (c echoCodec) Name() string                        { return c.name }
+
+func TestRegisterLookup(t *testing.T) {
+	const id byte = 200
+	c := echoCodec{name: "codec-test-echo"}
+	Register(id, c)
+
+	got, ok := Lookup(id)
+	if !ok || got.Name() != c.Name() {
+		t.Fatalf("Lookup(%d) = %v, %v; want %v, true", id, got, ok, c)
+	}
+
+	gotID, gotC, ok := LookupByName(c.Name())
+	if !ok || gotID != id || gotC.Name() != c.Name() {
+		t.Fatalf("LookupByName(%q) = %d, %v, %v; want %d, _, true", c.Name(), gotID, gotC, ok, id)
+	}
+
+	var buf bytes.Buffer
+	if err := got.Encode(&buf, bytes.NewReader([]byte("hello"))); err != nil {
+		t.Fatalf("Encode error: %v", err)
+	}
+	if buf.String() != "hello" {
+		t.Fatalf("Encode: got %q, want %q", buf.String(), "hello")
+	}
+}
+
+func TestLookupUnknown(t *testing.T) {
+	if _, ok := Lookup(255); ok {
+		t.Fatal("Lookup(255) = true, want false for an unregistered id")
+	}
+	if _, _, ok := LookupByName("no-such-codec"); ok {
+		t.Fatal("LookupByName for an unregistered name = true, want false")
+	}
+}
+
+func TestRegisterDuplicateIDPanics(t *testing.T) {
+	const id byte = 201
+	Register(id, echoCodec{name: "codec-test-dup-id-a"})
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected Register to panic on a duplicate id")
+		}
+	}()
+	Register(id, echoCodec{name: "codec-test-dup-id-b"})
+}
+
+func TestRegisterDuplicateNamePanics(t *testing.T) {
+	const name = "codec-test-dup-name"
+	Register(210, echoCodec{name: name})
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected Register to panic on a duplicate name")
+		}
+	}()
+	Register(211, echoCodec{name: name})
+}
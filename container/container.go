@@ -0,0 +1,166 @@
+// Package container wraps any registered codec.Codec in a self-describing
+// frame so a single encoding CLI (or future caller) can decode a file
+// without being told which algorithm produced it.
+package container
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+
+	"github.com/LessUp/Encoding/codec"
+)
+
+// magic opens every frame. 8 bytes keeps it distinguishable from the
+// shorter 4-byte magics the raw per-algorithm formats use.
+const magic = "ENCODING"
+
+// Frame flag bits.
+const (
+	// flagChecksum marks a trailing CRC32 (4 bytes, little-endian) over the
+	// uncompressed payload, written after the codec's compressed bytes.
+	flagChecksum = 1 << 0
+	// flagDictionary is reserved for a future shared-dictionary codec; no
+	// codec in this repository sets it yet, and Decode rejects it.
+	flagDictionary = 1 << 1
+)
+
+// Options configures Encode.
+type Options struct {
+	// Checksum appends a CRC32 trailer over the uncompressed bytes that
+	// Decode verifies. The zero Options leaves it off; the encoding CLI
+	// turns it on unless --no-checksum is given.
+	Checksum bool
+}
+
+// Encode reads all of r, compresses it with c, and writes a single framed
+// block to w: magic, id, flags, an uncompressed-length varint, the
+// compressed payload, and (when opts.Checksum) a CRC32 trailer.
+func Encode(w io.Writer, r io.Reader, id byte, c codec.Codec, opts Options) error {
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	var payload bytes.Buffer
+	if err := c.Encode(&payload, bytes.NewReader(raw)); err != nil {
+		return fmt.Errorf("container: %s encode: %w", c.Name(), err)
+	}
+
+	var flags byte
+	if opts.Checksum {
+		flags |= flagChecksum
+	}
+
+	head := make([]byte, 0, len(magic)+2+binary.MaxVarintLen64)
+	head = append(head, magic...)
+	head = append(head, id, flags)
+	head = binary.AppendUvarint(head, uint64(len(raw)))
+	if _, err := w.Write(head); err != nil {
+		return err
+	}
+	if _, err := w.Write(payload.Bytes()); err != nil {
+		return err
+	}
+	if !opts.Checksum {
+		return nil
+	}
+	var trailer [4]byte
+	binary.LittleEndian.PutUint32(trailer[:], crc32.ChecksumIEEE(raw))
+	_, err = w.Write(trailer[:])
+	return err
+}
+
+// Decode reads a single frame written by Encode from r, dispatches to the
+// codec its header names, and writes the decompressed bytes to w.
+func Decode(w io.Writer, r io.Reader) error {
+	head := make([]byte, len(magic)+2)
+	if _, err := io.ReadFull(r, head); err != nil {
+		return fmt.Errorf("container: reading header: %w", err)
+	}
+	if string(head[:len(magic)]) != magic {
+		return errors.New("container: bad magic")
+	}
+	id, flags := head[len(magic)], head[len(magic)+1]
+	if flags&flagDictionary != 0 {
+		return errors.New("container: dictionary frames are not supported")
+	}
+
+	br := bufio.NewReader(r)
+	rawLen, err := binary.ReadUvarint(br)
+	if err != nil {
+		return fmt.Errorf("container: reading length: %w", err)
+	}
+
+	rest, err := io.ReadAll(br)
+	if err != nil {
+		return err
+	}
+	var trailer []byte
+	if flags&flagChecksum != 0 {
+		if len(rest) < 4 {
+			return errors.New("container: truncated checksum trailer")
+		}
+		trailer, rest = rest[len(rest)-4:], rest[:len(rest)-4]
+	}
+
+	c, ok := codec.Lookup(id)
+	if !ok {
+		return fmt.Errorf("container: no codec registered for id %d", id)
+	}
+
+	var out bytes.Buffer
+	if err := c.Decode(&out, bytes.NewReader(rest)); err != nil {
+		return fmt.Errorf("container: %s decode: %w", c.Name(), err)
+	}
+	if uint64(out.Len()) != rawLen {
+		return fmt.Errorf("container: decoded length %d does not match header %d", out.Len(), rawLen)
+	}
+	if trailer != nil {
+		if binary.LittleEndian.Uint32(trailer) != crc32.ChecksumIEEE(out.Bytes()) {
+			return errors.New("container: checksum mismatch")
+		}
+	}
+	_, err = w.Write(out.Bytes())
+	return err
+}
+
+// Reader decodes a single framed block on the first call to Read, buffering
+// the result the way rangecoder.Reader buffers a decoded block.
+type Reader struct {
+	src     io.Reader
+	pending []byte
+	started bool
+	err     error
+}
+
+// NewReader returns a Reader that, on first Read, decodes the frame from r
+// and dispatches to whichever codec its header names.
+func NewReader(r io.Reader) *Reader {
+	return &Reader{src: r}
+}
+
+func (r *Reader) Read(p []byte) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+	if !r.started {
+		var buf bytes.Buffer
+		if err := Decode(&buf, r.src); err != nil {
+			r.err = err
+			return 0, err
+		}
+		r.pending = buf.Bytes()
+		r.started = true
+	}
+	if len(r.pending) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, r.pending)
+	r.pending = r.pending[n:]
+	return n, nil
+}
@@ -0,0 +1,150 @@
+package container
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"testing"
+
+	"github.com/LessUp/Encoding/codec"
+
+	_ "github.com/LessUp/Encoding/Run-Length/go"
+)
+
+func makeSeededData(n int, seed int64) []byte {
+	b := make([]byte, n)
+	r := rand.New(rand.NewSource(seed))
+	if n > 0 {
+		_, _ = r.Read(b)
+	}
+	return b
+}
+
+// TestRoundTrip exercises Encode/Decode through the real rle codec, with and
+// without the checksum trailer, across empty input and random data.
+func TestRoundTrip(t *testing.T) {
+	id, c, ok := codec.LookupByName("rle")
+	if !ok {
+		t.Fatal(`codec "rle" is not registered`)
+	}
+
+	for _, checksum := range []bool{true, false} {
+		for _, n := range []int{0, 1, 100, 10000} {
+			data := makeSeededData(n, 1)
+
+			var framed bytes.Buffer
+			if err := Encode(&framed, bytes.NewReader(data), id, c, Options{Checksum: checksum}); err != nil {
+				t.Fatalf("checksum=%v n=%d: Encode error: %v", checksum, n, err)
+			}
+
+			var out bytes.Buffer
+			if err := Decode(&out, bytes.NewReader(framed.Bytes())); err != nil {
+				t.Fatalf("checksum=%v n=%d: Decode error: %v", checksum, n, err)
+			}
+			if !bytes.Equal(out.Bytes(), data) {
+				t.Fatalf("checksum=%v n=%d: mismatch", checksum, n)
+			}
+		}
+	}
+}
+
+// TestReaderRoundTrip exercises the Reader wrapper the same way a caller
+// streaming through io.Copy would use it.
+func TestReaderRoundTrip(t *testing.T) {
+	id, c, ok := codec.LookupByName("rle")
+	if !ok {
+		t.Fatal(`codec "rle" is not registered`)
+	}
+	data := makeSeededData(5000, 2)
+
+	var framed bytes.Buffer
+	if err := Encode(&framed, bytes.NewReader(data), id, c, Options{Checksum: true}); err != nil {
+		t.Fatalf("Encode error: %v", err)
+	}
+
+	out, err := io.ReadAll(NewReader(bytes.NewReader(framed.Bytes())))
+	if err != nil {
+		t.Fatalf("Reader error: %v", err)
+	}
+	if !bytes.Equal(out, data) {
+		t.Fatal("Reader round trip mismatch")
+	}
+}
+
+func encodeRLE(t *testing.T, data []byte, checksum bool) []byte {
+	t.Helper()
+	id, c, ok := codec.LookupByName("rle")
+	if !ok {
+		t.Fatal(`codec "rle" is not registered`)
+	}
+	var framed bytes.Buffer
+	if err := Encode(&framed, bytes.NewReader(data), id, c, Options{Checksum: checksum}); err != nil {
+		t.Fatalf("Encode error: %v", err)
+	}
+	return framed.Bytes()
+}
+
+// TestDecodeBadMagicReturnsError checks the frame-magic guard.
+func TestDecodeBadMagicReturnsError(t *testing.T) {
+	var out bytes.Buffer
+	if err := Decode(&out, bytes.NewReader([]byte("not a container frame"))); err == nil {
+		t.Fatal("expected an error for bad magic, got nil")
+	}
+}
+
+// TestDecodeTruncatedHeaderReturnsError checks that cutting the frame off
+// partway through the header is reported as an error, not a panic.
+func TestDecodeTruncatedHeaderReturnsError(t *testing.T) {
+	framed := encodeRLE(t, []byte("hello world"), true)
+	for _, n := range []int{0, 1, 4, len(magic), len(magic) + 1} {
+		if n > len(framed) {
+			continue
+		}
+		var out bytes.Buffer
+		if err := Decode(&out, bytes.NewReader(framed[:n])); err == nil {
+			t.Fatalf("n=%d: expected an error for a truncated header, got nil", n)
+		}
+	}
+}
+
+// TestDecodeCorruptedChecksumReturnsError flips the last byte of the frame,
+// which belongs to the CRC32 trailer, and checks Decode reports the checksum
+// mismatch rather than returning the data silently.
+func TestDecodeCorruptedChecksumReturnsError(t *testing.T) {
+	data := []byte("hello world, this is test data for the container checksum")
+	framed := encodeRLE(t, data, true)
+
+	corrupted := append([]byte(nil), framed...)
+	corrupted[len(corrupted)-1] ^= 0xFF
+
+	var out bytes.Buffer
+	if err := Decode(&out, bytes.NewReader(corrupted)); err == nil {
+		t.Fatal("expected a checksum mismatch error, got nil")
+	}
+}
+
+// TestDecodeUnknownCodecReturnsError patches a valid frame's codec id to one
+// that isn't registered and checks Decode reports it instead of panicking.
+func TestDecodeUnknownCodecReturnsError(t *testing.T) {
+	framed := encodeRLE(t, []byte("hello"), false)
+	corrupted := append([]byte(nil), framed...)
+	corrupted[len(magic)] = 255 // no codec registers id 255
+
+	var out bytes.Buffer
+	if err := Decode(&out, bytes.NewReader(corrupted)); err == nil {
+		t.Fatal("expected an error for an unregistered codec id, got nil")
+	}
+}
+
+// TestDecodeDictionaryFlagReturnsError checks the reserved dictionary flag
+// is rejected rather than silently ignored.
+func TestDecodeDictionaryFlagReturnsError(t *testing.T) {
+	framed := encodeRLE(t, []byte("hello"), false)
+	corrupted := append([]byte(nil), framed...)
+	corrupted[len(magic)+1] |= flagDictionary
+
+	var out bytes.Buffer
+	if err := Decode(&out, bytes.NewReader(corrupted)); err == nil {
+		t.Fatal("expected an error for the dictionary flag, got nil")
+	}
+}
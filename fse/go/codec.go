@@ -0,0 +1,43 @@
+package fse
+
+import (
+	"io"
+
+	"github.com/LessUp/Encoding/codec"
+)
+
+// Codec adapts the one-shot Encode/Decode functions to the codec.Codec
+// interface used by package container and the encoding CLI.
+type Codec struct{}
+
+func (Codec) Encode(w io.Writer, r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	out, err := Encode(data)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(out)
+	return err
+}
+
+func (Codec) Decode(w io.Writer, r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	out, err := Decode(data)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(out)
+	return err
+}
+
+func (Codec) Name() string { return "fse" }
+
+func init() {
+	codec.Register(codec.IDFSE, Codec{})
+}
@@ -0,0 +1,296 @@
+// Package fse implements Finite State Entropy (tabled ANS), a faster
+// alternative to the arithmetic/range coder in package rangecoder. It
+// exposes the same Encode/Decode signature so the two are drop-in
+// interchangeable.
+package fse
+
+import (
+	"encoding/binary"
+	"errors"
+	"math/bits"
+
+	rangecoder "github.com/LessUp/Encoding/range/go"
+)
+
+const (
+	symbolLimit = 257
+	eofSymbol   = symbolLimit - 1
+
+	// tableLog sizes the state table at 1<<tableLog slots; 12 sits in the
+	// middle of the usual 9-14 range and keeps the state tables small.
+	tableLog  = 12
+	tableSize = 1 << tableLog
+
+	magic0, magic1, magic2, magic3 = 'F', 'S', 'E', '1'
+)
+
+// histogram builds a raw order-0 byte histogram plus one guaranteed count
+// for the EOF symbol, the same shape as rangecoder.buildFrequencies.
+func histogram(data []byte) []uint32 {
+	freq := make([]uint32, symbolLimit)
+	for _, b := range data {
+		freq[int(b)]++
+	}
+	freq[eofSymbol] = 1
+	return freq
+}
+
+// normalizeFrequencies scales freq so it sums to exactly 1<<tableLog, since
+// every FSE table slot must be assigned to a symbol. It shares its scaling
+// and remainder-fixup logic with rangecoder.ScaleFrequencies, called here
+// with exact=true instead of that package's own capped-total use.
+func normalizeFrequencies(freq []uint32) []uint32 {
+	counts := append([]uint32(nil), freq...)
+	rangecoder.ScaleFrequencies(counts, tableSize, true)
+	return counts
+}
+
+// spread assigns every one of the tableSize slots to a symbol, walking the
+// table with Yann Collet's step = (5*size>>2)+3, which is coprime with
+// tableSize for the 9-14 tableLog range used here.
+func spread(counts []uint32) []uint16 {
+	step := (5*uint32(tableSize)>>2 + 3) & (tableSize - 1)
+	symbolAt := make([]uint16, tableSize)
+	pos := uint32(0)
+	for s, c := range counts {
+		for i := uint32(0); i < c; i++ {
+			symbolAt[pos] = uint16(s)
+			pos = (pos + step) & (tableSize - 1)
+		}
+	}
+	return symbolAt
+}
+
+func highbit(x uint32) uint {
+	return uint(bits.Len32(x) - 1)
+}
+
+// dtableEntry drives one decode step: emit symbol, then read nbBits bits
+// and add them to newBase to find the next state.
+type dtableEntry struct {
+	symbol  uint16
+	nbBits  uint8
+	newBase uint32
+}
+
+func buildDecodeTable(counts []uint32, symbolAt []uint16) []dtableEntry {
+	next := append([]uint32(nil), counts...)
+	dtable := make([]dtableEntry, tableSize)
+	for pos, s := range symbolAt {
+		rank := next[s]
+		next[s]++
+		nb := tableLog - highbit(rank)
+		newBase := (rank << nb) - tableSize
+		dtable[pos] = dtableEntry{symbol: s, nbBits: uint8(nb), newBase: newBase}
+	}
+	return dtable
+}
+
+// encSymbol holds everything needed to encode one occurrence of a symbol:
+// the ascending table positions it owns (indexed by rank, low to high), and
+// the two-band split that buildDecodeTable's newBase formula produces: low
+// target states decode from the high ranks using tableLog-k-1 bits, high
+// target states decode from the low ranks using tableLog-k bits, where
+// k = highbit(count).
+type encSymbol struct {
+	occ      []uint32
+	k        uint
+	nLow     uint32
+	boundary uint32
+}
+
+func buildEncodeTable(counts []uint32, symbolAt []uint16) []encSymbol {
+	enc := make([]encSymbol, len(counts))
+	for pos, s := range symbolAt {
+		enc[s].occ = append(enc[s].occ, uint32(pos))
+	}
+	for s, c := range counts {
+		if c == 0 {
+			continue
+		}
+		k := highbit(c)
+		enc[s].k = k
+		enc[s].nLow = (uint32(1) << (k + 1)) - c
+		enc[s].boundary = (c - (uint32(1) << k)) << (tableLog - k)
+	}
+	return enc
+}
+
+// encodeStep is the exact inverse of one decode transition: given the state
+// the decoder should reach after emitting symbol, it returns the state the
+// decoder must have been in beforehand (the encoder's new current state)
+// plus the bits that must appear in the stream to make that transition.
+func encodeStep(enc []encSymbol, state uint32, symbol uint32) (newState, value uint32, nbBits uint) {
+	e := enc[symbol]
+	var rankOffset uint32
+	if state < e.boundary {
+		nbBits = tableLog - e.k - 1
+		rankOffset = e.nLow + (state >> nbBits)
+		value = state & (1<<nbBits - 1)
+	} else {
+		nbBits = tableLog - e.k
+		rem := state - e.boundary
+		rankOffset = rem >> nbBits
+		value = rem & (1<<nbBits - 1)
+	}
+	newState = e.occ[rankOffset]
+	return newState, value, nbBits
+}
+
+type bitsOut struct {
+	value  uint32
+	nbBits uint
+}
+
+// Encode table-codes input with a single order-0 FSE/tANS model, mirroring
+// rangecoder.Encode's one-shot []byte signature.
+func Encode(input []byte) ([]byte, error) {
+	freq := histogram(input)
+	counts := normalizeFrequencies(freq)
+	symbolAt := spread(counts)
+	enc := buildEncodeTable(counts, symbolAt)
+
+	// FSE/tANS is LIFO: encode from the last symbol back to the first so a
+	// forward decode reproduces the original order.
+	state := uint32(0)
+	state, _, _ = encodeStep(enc, state, eofSymbol)
+
+	pending := make([]bitsOut, 0, len(input))
+	for i := len(input) - 1; i >= 0; i-- {
+		var v uint32
+		var nb uint
+		state, v, nb = encodeStep(enc, state, uint32(input[i]))
+		pending = append(pending, bitsOut{value: v, nbBits: nb})
+	}
+
+	out := make([]byte, 0, len(input)+4*symbolLimit+16)
+	out = append(out, magic0, magic1, magic2, magic3, byte(tableLog))
+	out = appendU32LE(out, uint32(len(input)))
+	for _, c := range counts {
+		out = appendU32LE(out, c)
+	}
+	out = appendU32LE(out, state)
+
+	bw := newBitWriter()
+	for i := len(pending) - 1; i >= 0; i-- {
+		bw.writeBits(pending[i].value, pending[i].nbBits)
+	}
+	out = append(out, bw.flush()...)
+	return out, nil
+}
+
+// Decode reverses Encode, matching rangecoder.Decode's signature. It decodes
+// exactly the declared raw length rather than running the state machine
+// until it happens to land on eofSymbol: a truncated or corrupted payload
+// can otherwise keep the state table cycling through non-EOF entries
+// forever, since the bit reader silently zero-pads past the end of its
+// input instead of signalling exhaustion.
+func Decode(encoded []byte) ([]byte, error) {
+	if len(encoded) < 5+4+4*symbolLimit+4 {
+		return nil, errors.New("fse: input too short")
+	}
+	if encoded[0] != magic0 || encoded[1] != magic1 || encoded[2] != magic2 || encoded[3] != magic3 {
+		return nil, errors.New("fse: bad magic")
+	}
+	if encoded[4] != tableLog {
+		return nil, errors.New("fse: unsupported table log")
+	}
+	pos := 5
+	rawLen := binary.LittleEndian.Uint32(encoded[pos:])
+	pos += 4
+	counts := make([]uint32, symbolLimit)
+	for i := range counts {
+		counts[i] = binary.LittleEndian.Uint32(encoded[pos:])
+		pos += 4
+	}
+	state := binary.LittleEndian.Uint32(encoded[pos:])
+	pos += 4
+
+	symbolAt := spread(counts)
+	dtable := buildDecodeTable(counts, symbolAt)
+
+	br := newBitReader(encoded[pos:])
+	out := make([]byte, 0, rawLen)
+	for i := uint32(0); i < rawLen; i++ {
+		entry := dtable[state]
+		if entry.symbol == eofSymbol {
+			return nil, errors.New("fse: corrupt stream: hit EOF symbol before declared length")
+		}
+		out = append(out, byte(entry.symbol))
+		bitsRead := br.readBits(uint(entry.nbBits))
+		state = entry.newBase + bitsRead
+	}
+	return out, nil
+}
+
+func appendU32LE(out []byte, v uint32) []byte {
+	return append(out, byte(v), byte(v>>8), byte(v>>16), byte(v>>24))
+}
+
+// bitWriter/bitReader are MSB-first, matching the bit order used elsewhere
+// in this repo (e.g. the huffman package's BitWriter).
+type bitWriter struct {
+	buf          []byte
+	buffer       byte
+	bitsInBuffer uint
+}
+
+func newBitWriter() *bitWriter {
+	return &bitWriter{}
+}
+
+func (w *bitWriter) writeBits(value uint32, n uint) {
+	for i := int(n) - 1; i >= 0; i-- {
+		bit := byte((value >> uint(i)) & 1)
+		w.buffer = (w.buffer << 1) | bit
+		w.bitsInBuffer++
+		if w.bitsInBuffer == 8 {
+			w.buf = append(w.buf, w.buffer)
+			w.buffer = 0
+			w.bitsInBuffer = 0
+		}
+	}
+}
+
+func (w *bitWriter) flush() []byte {
+	if w.bitsInBuffer > 0 {
+		w.buffer <<= 8 - w.bitsInBuffer
+		w.buf = append(w.buf, w.buffer)
+		w.buffer = 0
+		w.bitsInBuffer = 0
+	}
+	return w.buf
+}
+
+type bitReader struct {
+	data          []byte
+	pos           int
+	currentByte   byte
+	bitsRemaining uint
+}
+
+func newBitReader(data []byte) *bitReader {
+	return &bitReader{data: data}
+}
+
+func (r *bitReader) readBit() uint32 {
+	if r.bitsRemaining == 0 {
+		if r.pos < len(r.data) {
+			r.currentByte = r.data[r.pos]
+			r.pos++
+		} else {
+			r.currentByte = 0
+		}
+		r.bitsRemaining = 8
+	}
+	r.bitsRemaining--
+	return uint32((r.currentByte >> r.bitsRemaining) & 1)
+}
+
+func (r *bitReader) readBits(n uint) uint32 {
+	var v uint32
+	for i := uint(0); i < n; i++ {
+		v = (v << 1) | r.readBit()
+	}
+	return v
+}
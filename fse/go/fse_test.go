@@ -0,0 +1,97 @@
+package fse
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func makeSeededData(n int, seed int64) []byte {
+	b := make([]byte, n)
+	r := rand.New(rand.NewSource(seed))
+	if n > 0 {
+		_, _ = r.Read(b)
+	}
+	return b
+}
+
+// TestRoundTrip exercises Encode/Decode across empty input, small skewed
+// inputs (one or two distinct bytes, the case most likely to starve other
+// symbols' table slots), and random data of several sizes and seeds.
+func TestRoundTrip(t *testing.T) {
+	cases := map[string][]byte{
+		"empty":       {},
+		"one byte":    {0x42},
+		"all same":    bytes.Repeat([]byte{0x07}, 5000),
+		"two symbols": bytes.Repeat([]byte{0x00, 0xFF}, 2500),
+	}
+	for name, data := range cases {
+		enc, err := Encode(data)
+		if err != nil {
+			t.Fatalf("%s: encode error: %v", name, err)
+		}
+		dec, err := Decode(enc)
+		if err != nil {
+			t.Fatalf("%s: decode error: %v", name, err)
+		}
+		if !bytes.Equal(dec, data) {
+			t.Fatalf("%s: mismatch: got %v, want %v", name, dec, data)
+		}
+	}
+
+	for _, n := range []int{0, 1, 2, 100, 1000, 65536} {
+		for _, seed := range []int64{1, 2, 3} {
+			data := makeSeededData(n, seed)
+			enc, err := Encode(data)
+			if err != nil {
+				t.Fatalf("n=%d seed=%d: encode error: %v", n, seed, err)
+			}
+			dec, err := Decode(enc)
+			if err != nil {
+				t.Fatalf("n=%d seed=%d: decode error: %v", n, seed, err)
+			}
+			if !bytes.Equal(dec, data) {
+				t.Fatalf("n=%d seed=%d: mismatch", n, seed)
+			}
+		}
+	}
+}
+
+// TestDecodeTruncatedDoesNotHang feeds Decode a header that's valid but
+// whose payload was cut short entirely. Decode must still return within a
+// bounded time: previously the state machine looped until it happened to
+// land on the EOF symbol, which a truncated or corrupted payload may never
+// do, since the bit reader silently zero-pads once its input runs out, and
+// roughly one in sixty initial states never reached it.
+func TestDecodeTruncatedDoesNotHang(t *testing.T) {
+	data := []byte("hello world, this is a reasonably long test string for fse")
+	enc, err := Encode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	headerLen := 5 + 4 + 4*symbolLimit + 4
+	truncated := append([]byte(nil), enc[:headerLen]...)
+
+	done := make(chan struct{})
+	go func() {
+		Decode(truncated)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Decode hung on a truncated payload instead of returning")
+	}
+}
+
+// TestDecodeShortInputReturnsError checks the length guard rejects inputs
+// shorter than the fixed header without panicking.
+func TestDecodeShortInputReturnsError(t *testing.T) {
+	for _, n := range []int{0, 1, 4, 8} {
+		if _, err := Decode(make([]byte, n)); err == nil {
+			t.Fatalf("len=%d: expected error, got nil", n)
+		}
+	}
+}
@@ -0,0 +1,712 @@
+package huffman
+
+import (
+	"bufio"
+	"container/heap"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/LessUp/Encoding/codec"
+)
+
+const (
+	SymbolLimit = 257
+	EOFSymbol   = SymbolLimit - 1
+
+	// maxCodeLength bounds canonical code lengths so decoding fits in a
+	// single [1<<maxCodeLength]uint16 lookup table.
+	maxCodeLength = 15
+)
+
+type Node struct {
+	symbol uint32
+	freq   uint64
+	left   *Node
+	right  *Node
+}
+
+func isLeaf(n *Node) bool {
+	return n.left == nil && n.right == nil
+}
+
+type nodeHeap []*Node
+
+func (h nodeHeap) Len() int { return len(h) }
+
+func (h nodeHeap) Less(i, j int) bool {
+	if h[i].freq != h[j].freq {
+		return h[i].freq < h[j].freq
+	}
+	return h[i].symbol < h[j].symbol
+}
+
+func (h nodeHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *nodeHeap) Push(x interface{}) {
+	*h = append(*h, x.(*Node))
+}
+
+func (h *nodeHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[:n-1]
+	return x
+}
+
+func buildTree(freq []uint32) *Node {
+	h := &nodeHeap{}
+	heap.Init(h)
+	for s := 0; s < SymbolLimit; s++ {
+		if freq[s] == 0 {
+			continue
+		}
+		n := &Node{
+			symbol: uint32(s),
+			freq:   uint64(freq[s]),
+			left:   nil,
+			right:  nil,
+		}
+		heap.Push(h, n)
+	}
+	if h.Len() == 0 {
+		return &Node{symbol: uint32(EOFSymbol), freq: 1}
+	}
+	if h.Len() == 1 {
+		only := heap.Pop(h).(*Node)
+		parent := &Node{symbol: 0, freq: only.freq, left: only, right: nil}
+		heap.Push(h, parent)
+	}
+	for h.Len() > 1 {
+		a := heap.Pop(h).(*Node)
+		b := heap.Pop(h).(*Node)
+		parent := &Node{
+			symbol: 0,
+			freq:   a.freq + b.freq,
+			left:   a,
+			right:  b,
+		}
+		heap.Push(h, parent)
+	}
+	return heap.Pop(h).(*Node)
+}
+
+// codeLengths derives a canonical code length per symbol. It starts from the
+// plain Huffman tree and only pays for the package-merge limiting pass when
+// that tree actually produced a code longer than maxCodeLength.
+func codeLengths(freq []uint32) []int {
+	lengths := make([]int, SymbolLimit)
+	assignDepths(buildTree(freq), 0, lengths)
+
+	longest := 0
+	for _, l := range lengths {
+		if l > longest {
+			longest = l
+		}
+	}
+	if longest > maxCodeLength {
+		lengths = packageMerge(freq, maxCodeLength)
+	}
+	return lengths
+}
+
+func assignDepths(node *Node, depth int, lengths []int) {
+	if node == nil {
+		return
+	}
+	if isLeaf(node) {
+		if depth == 0 {
+			depth = 1 // lone-symbol tree: single leaf still needs a 1-bit code
+		}
+		lengths[node.symbol] = depth
+		return
+	}
+	assignDepths(node.left, depth+1, lengths)
+	assignDepths(node.right, depth+1, lengths)
+}
+
+// pmNode is a node in the package-merge coin lists: a weight and the set of
+// original symbols that contribute one unit of code length if this node is
+// selected.
+type pmNode struct {
+	weight  uint64
+	symbols []int
+}
+
+// packageMerge computes optimal code lengths capped at maxLen, using the
+// Katajainen/Moffat/Turpin package-merge (coin collector) algorithm.
+func packageMerge(freq []uint32, maxLen int) []int {
+	type item struct {
+		sym  int
+		freq uint64
+	}
+	var items []item
+	for s, f := range freq {
+		if f > 0 {
+			items = append(items, item{s, uint64(f)})
+		}
+	}
+	lengths := make([]int, len(freq))
+	n := len(items)
+	if n == 0 {
+		return lengths
+	}
+	if n == 1 {
+		lengths[items[0].sym] = 1
+		return lengths
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].freq != items[j].freq {
+			return items[i].freq < items[j].freq
+		}
+		return items[i].sym < items[j].sym
+	})
+
+	original := make([]pmNode, n)
+	for i, it := range items {
+		original[i] = pmNode{weight: it.freq, symbols: []int{it.sym}}
+	}
+
+	list := original
+	for t := 2; t <= maxLen; t++ {
+		list = mergeSortedNodes(packagePairs(list), original)
+	}
+
+	take := 2 * (n - 1)
+	if take > len(list) {
+		take = len(list)
+	}
+	for _, node := range list[:take] {
+		for _, s := range node.symbols {
+			lengths[s]++
+		}
+	}
+	return lengths
+}
+
+func packagePairs(list []pmNode) []pmNode {
+	out := make([]pmNode, 0, len(list)/2)
+	for i := 0; i+1 < len(list); i += 2 {
+		symbols := make([]int, 0, len(list[i].symbols)+len(list[i+1].symbols))
+		symbols = append(symbols, list[i].symbols...)
+		symbols = append(symbols, list[i+1].symbols...)
+		out = append(out, pmNode{weight: list[i].weight + list[i+1].weight, symbols: symbols})
+	}
+	return out
+}
+
+func mergeSortedNodes(a, b []pmNode) []pmNode {
+	out := make([]pmNode, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		if a[i].weight <= b[j].weight {
+			out = append(out, a[i])
+			i++
+		} else {
+			out = append(out, b[j])
+			j++
+		}
+	}
+	out = append(out, a[i:]...)
+	out = append(out, b[j:]...)
+	return out
+}
+
+// canonicalCodes assigns each symbol the next available code of its length,
+// walking lengths shortest-first and symbols in numeric order, per RFC 1951
+// 3.2.2.
+func canonicalCodes(lengths []int) ([]uint32, error) {
+	var count [maxCodeLength + 1]int
+	for _, l := range lengths {
+		if l == 0 {
+			continue
+		}
+		if l > maxCodeLength {
+			return nil, fmt.Errorf("编码长度超出上限: %d", l)
+		}
+		count[l]++
+	}
+
+	var nextCode [maxCodeLength + 1]uint32
+	code := uint32(0)
+	for bits := 1; bits <= maxCodeLength; bits++ {
+		code = (code + uint32(count[bits-1])) << 1
+		nextCode[bits] = code
+	}
+
+	codes := make([]uint32, len(lengths))
+	for s, l := range lengths {
+		if l == 0 {
+			continue
+		}
+		codes[s] = nextCode[l]
+		nextCode[l]++
+	}
+	return codes, nil
+}
+
+// buildDecodeTable expands the canonical codes into a flat lookup indexed by
+// the next maxCodeLength bits of the stream: entry = symbol | length<<9.
+func buildDecodeTable(lengths []int, codes []uint32) [1 << maxCodeLength]uint16 {
+	var table [1 << maxCodeLength]uint16
+	for s, l := range lengths {
+		if l == 0 {
+			continue
+		}
+		shift := uint(maxCodeLength - l)
+		base := codes[s] << shift
+		entry := uint16(s) | uint16(l)<<9
+		for pad := uint32(0); pad < uint32(1)<<shift; pad++ {
+			table[base+pad] = entry
+		}
+	}
+	return table
+}
+
+type BitWriter struct {
+	w            *bufio.Writer
+	buffer       byte
+	bitsInBuffer uint8
+}
+
+func NewBitWriter(w io.Writer) *BitWriter {
+	return &BitWriter{w: bufio.NewWriter(w)}
+}
+
+func (b *BitWriter) WriteBit(bit int) error {
+	b.buffer = (b.buffer << 1) | byte(bit&1)
+	b.bitsInBuffer++
+	if b.bitsInBuffer == 8 {
+		if err := b.w.WriteByte(b.buffer); err != nil {
+			return err
+		}
+		b.bitsInBuffer = 0
+		b.buffer = 0
+	}
+	return nil
+}
+
+// WriteCode emits the length low-order bits of code, most significant bit
+// first, matching how buildDecodeTable indexes them.
+func (b *BitWriter) WriteCode(code uint32, length int) error {
+	for i := length - 1; i >= 0; i-- {
+		if err := b.WriteBit(int((code >> uint(i)) & 1)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *BitWriter) Flush() error {
+	if b.bitsInBuffer > 0 {
+		b.buffer <<= (8 - b.bitsInBuffer)
+		if err := b.w.WriteByte(b.buffer); err != nil {
+			return err
+		}
+		b.bitsInBuffer = 0
+		b.buffer = 0
+	}
+	return b.w.Flush()
+}
+
+// sliceBitWriter is BitWriter's counterpart for AppendEncoded: it packs bits
+// directly into a []byte instead of going through a bufio.Writer.
+type sliceBitWriter struct {
+	buf          []byte
+	buffer       byte
+	bitsInBuffer uint8
+}
+
+func (b *sliceBitWriter) writeBit(bit int) {
+	b.buffer = (b.buffer << 1) | byte(bit&1)
+	b.bitsInBuffer++
+	if b.bitsInBuffer == 8 {
+		b.buf = append(b.buf, b.buffer)
+		b.bitsInBuffer = 0
+		b.buffer = 0
+	}
+}
+
+// writeCode emits the length low-order bits of code, most significant bit
+// first, matching how buildDecodeTable indexes them.
+func (b *sliceBitWriter) writeCode(code uint32, length int) {
+	for i := length - 1; i >= 0; i-- {
+		b.writeBit(int((code >> uint(i)) & 1))
+	}
+}
+
+func (b *sliceBitWriter) flush() {
+	if b.bitsInBuffer > 0 {
+		b.buf = append(b.buf, b.buffer<<(8-b.bitsInBuffer))
+		b.bitsInBuffer = 0
+		b.buffer = 0
+	}
+}
+
+// tableBitReader keeps a rolling window of unread bits so the decoder can
+// peek maxCodeLength bits at a time for a single table lookup.
+type tableBitReader struct {
+	r      *bufio.Reader
+	window uint32
+	nBits  uint
+}
+
+func newTableBitReader(r *bufio.Reader) *tableBitReader {
+	return &tableBitReader{r: r}
+}
+
+func (t *tableBitReader) fill() {
+	for t.nBits <= 24 {
+		b, err := t.r.ReadByte()
+		if err != nil {
+			b = 0 // pad with zero bits once the stream is exhausted
+		}
+		t.window = (t.window << 8) | uint32(b)
+		t.nBits += 8
+	}
+}
+
+// peek returns the next maxCodeLength bits without consuming them.
+func (t *tableBitReader) peek() uint32 {
+	t.fill()
+	return (t.window >> (t.nBits - maxCodeLength)) & ((1 << maxCodeLength) - 1)
+}
+
+func (t *tableBitReader) consume(n uint) {
+	t.nBits -= n
+}
+
+// sliceTableBitReader is tableBitReader's counterpart for DecodeInto: it
+// peeks maxCodeLength bits at a time directly out of a []byte.
+type sliceTableBitReader struct {
+	src    []byte
+	pos    int
+	window uint32
+	nBits  uint
+}
+
+func (t *sliceTableBitReader) fill() {
+	for t.nBits <= 24 {
+		var b byte
+		if t.pos < len(t.src) {
+			b = t.src[t.pos]
+			t.pos++
+		} // pad with zero bits once the stream is exhausted
+		t.window = (t.window << 8) | uint32(b)
+		t.nBits += 8
+	}
+}
+
+func (t *sliceTableBitReader) peek() uint32 {
+	t.fill()
+	return (t.window >> (t.nBits - maxCodeLength)) & ((1 << maxCodeLength) - 1)
+}
+
+func (t *sliceTableBitReader) consume(n uint) {
+	t.nBits -= n
+}
+
+func buildFrequencies(data []byte) []uint32 {
+	freq := make([]uint32, SymbolLimit)
+	for _, b := range data {
+		freq[int(b)]++
+	}
+	freq[EOFSymbol] = 1
+	return freq
+}
+
+// writeLengths packs one 4-bit length per symbol (two symbols per byte),
+// shrinking the header from the old 257*uint32 frequency table to ~130 bytes.
+func writeLengths(w io.Writer, lengths []int) error {
+	buf := make([]byte, (SymbolLimit+1)/2)
+	for i := 0; i < SymbolLimit; i++ {
+		nibble := byte(lengths[i] & 0xF)
+		if i%2 == 0 {
+			buf[i/2] = nibble
+		} else {
+			buf[i/2] |= nibble << 4
+		}
+	}
+	_, err := w.Write(buf)
+	return err
+}
+
+// appendLengths is writeLengths' counterpart for AppendEncoded: it packs one
+// 4-bit length per symbol and appends the result to dst.
+func appendLengths(dst []byte, lengths []int) []byte {
+	buf := make([]byte, (SymbolLimit+1)/2)
+	for i := 0; i < SymbolLimit; i++ {
+		nibble := byte(lengths[i] & 0xF)
+		if i%2 == 0 {
+			buf[i/2] = nibble
+		} else {
+			buf[i/2] |= nibble << 4
+		}
+	}
+	return append(dst, buf...)
+}
+
+func readLengthsFrom(src []byte, pos int) ([]int, int, error) {
+	need := (SymbolLimit + 1) / 2
+	if pos+need > len(src) {
+		return nil, pos, fmt.Errorf("读取长度表失败: 输入截断")
+	}
+	lengths := make([]int, SymbolLimit)
+	for i := 0; i < SymbolLimit; i++ {
+		b := src[pos+i/2]
+		if i%2 == 0 {
+			lengths[i] = int(b & 0xF)
+		} else {
+			lengths[i] = int(b >> 4)
+		}
+	}
+	return lengths, pos + need, nil
+}
+
+func readLengths(r io.Reader) ([]int, error) {
+	buf := make([]byte, (SymbolLimit+1)/2)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return nil, fmt.Errorf("读取长度表失败: %w", err)
+	}
+	lengths := make([]int, SymbolLimit)
+	for i := 0; i < SymbolLimit; i++ {
+		if i%2 == 0 {
+			lengths[i] = int(buf[i/2] & 0xF)
+		} else {
+			lengths[i] = int(buf[i/2] >> 4)
+		}
+	}
+	return lengths, nil
+}
+
+// grow returns dst with at least extra bytes of spare capacity, copying it
+// to a larger backing array only when necessary.
+func grow(dst []byte, extra int) []byte {
+	if cap(dst)-len(dst) >= extra {
+		return dst
+	}
+	grown := make([]byte, len(dst), len(dst)+extra)
+	copy(grown, dst)
+	return grown
+}
+
+// headerSize bounds the static cost AppendEncoded adds on top of len(src):
+// the 4-byte magic, the 4-byte raw length, plus the packed 4-bit-per-symbol
+// length table.
+const headerSize = 4 + 4 + (SymbolLimit+1)/2
+
+func putU32LE(dst []byte, v uint32) []byte {
+	return append(dst, byte(v), byte(v>>8), byte(v>>16), byte(v>>24))
+}
+
+func getU32LE(src []byte) uint32 {
+	return uint32(src[0]) | uint32(src[1])<<8 | uint32(src[2])<<16 | uint32(src[3])<<24
+}
+
+// Encode reads all of r, builds a canonical Huffman code over its byte
+// frequencies, and writes the framed, bit-packed result to w.
+func Encode(w io.Writer, r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("读取输入失败: %w", err)
+	}
+	freq := buildFrequencies(data)
+	lengths := codeLengths(freq)
+	codes, err := canonicalCodes(lengths)
+	if err != nil {
+		return err
+	}
+
+	if _, err := w.Write([]byte{'H', 'F', 'M', '2'}); err != nil {
+		return err
+	}
+	if _, err := w.Write(putU32LE(nil, uint32(len(data)))); err != nil {
+		return err
+	}
+	if err := writeLengths(w, lengths); err != nil {
+		return err
+	}
+
+	bw := NewBitWriter(w)
+	for _, b := range data {
+		if err := bw.WriteCode(codes[int(b)], lengths[int(b)]); err != nil {
+			return err
+		}
+	}
+	if err := bw.WriteCode(codes[EOFSymbol], lengths[EOFSymbol]); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// Decode reverses Encode, reading a framed stream from r and writing the
+// original bytes to w. It decodes exactly the declared raw length rather
+// than running until it happens to land on EOFSymbol: a truncated or
+// corrupted bitstream can otherwise keep producing non-EOF table entries
+// forever, since tableBitReader silently zero-pads once its input is
+// exhausted.
+func Decode(w io.Writer, r io.Reader) error {
+	br := bufio.NewReader(r)
+	magic := make([]byte, 4)
+	if _, err := io.ReadFull(br, magic); err != nil || magic[0] != 'H' || magic[1] != 'F' || magic[2] != 'M' || magic[3] != '2' {
+		return fmt.Errorf("输入数据格式非法")
+	}
+	lenBuf := make([]byte, 4)
+	if _, err := io.ReadFull(br, lenBuf); err != nil {
+		return fmt.Errorf("读取长度失败: %w", err)
+	}
+	rawLen := getU32LE(lenBuf)
+
+	lengths, err := readLengths(br)
+	if err != nil {
+		return err
+	}
+	codes, err := canonicalCodes(lengths)
+	if err != nil {
+		return err
+	}
+	table := buildDecodeTable(lengths, codes)
+
+	bw := bufio.NewWriter(w)
+	tbr := newTableBitReader(br)
+	for i := uint32(0); i < rawLen; i++ {
+		entry := table[tbr.peek()]
+		length := uint(entry >> 9)
+		if length == 0 {
+			return fmt.Errorf("输入数据损坏或截断")
+		}
+		tbr.consume(length)
+
+		symbol := entry & 0x1FF
+		if symbol == EOFSymbol {
+			return fmt.Errorf("输入数据损坏: 提前遇到结束符")
+		}
+		if err := bw.WriteByte(byte(symbol)); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// AppendEncoded Huffman-codes src and appends the framed result to dst,
+// growing dst once up front by headerSize+len(src)+16 bytes. Output longer
+// than that estimate (codes averaging more than 8 bits per byte, as on
+// random input) grows dst again through append.
+func AppendEncoded(dst, src []byte) []byte {
+	dst = grow(dst, headerSize+len(src)+16)
+	freq := buildFrequencies(src)
+	lengths := codeLengths(freq)
+	codes, err := canonicalCodes(lengths)
+	if err != nil {
+		// codeLengths never produces lengths canonicalCodes rejects; a
+		// failure here means the tree-building invariants broke.
+		panic(err)
+	}
+
+	dst = append(dst, 'H', 'F', 'M', '2')
+	dst = putU32LE(dst, uint32(len(src)))
+	dst = appendLengths(dst, lengths)
+
+	bw := sliceBitWriter{buf: dst}
+	for _, b := range src {
+		bw.writeCode(codes[int(b)], lengths[int(b)])
+	}
+	bw.writeCode(codes[EOFSymbol], lengths[EOFSymbol])
+	bw.flush()
+	return bw.buf
+}
+
+// DecodeInto reverses AppendEncoded, appending the decompressed bytes to dst
+// and returning the grown slice. Like Decode, it stops after exactly the
+// declared raw length of symbols instead of scanning for EOFSymbol.
+func DecodeInto(dst, src []byte) ([]byte, error) {
+	if len(src) < 8 || src[0] != 'H' || src[1] != 'F' || src[2] != 'M' || src[3] != '2' {
+		return dst, fmt.Errorf("输入数据格式非法")
+	}
+	rawLen := getU32LE(src[4:8])
+
+	lengths, pos, err := readLengthsFrom(src, 8)
+	if err != nil {
+		return dst, err
+	}
+	codes, err := canonicalCodes(lengths)
+	if err != nil {
+		return dst, err
+	}
+	table := buildDecodeTable(lengths, codes)
+
+	tbr := sliceTableBitReader{src: src, pos: pos}
+	for i := uint32(0); i < rawLen; i++ {
+		entry := table[tbr.peek()]
+		length := uint(entry >> 9)
+		if length == 0 {
+			return dst, fmt.Errorf("输入数据损坏或截断")
+		}
+		tbr.consume(length)
+
+		symbol := entry & 0x1FF
+		if symbol == EOFSymbol {
+			return dst, fmt.Errorf("输入数据损坏: 提前遇到结束符")
+		}
+		dst = append(dst, byte(symbol))
+	}
+	return dst, nil
+}
+
+// HuffmanEncodeFile compresses inputPath to outputPath, logging any error
+// to stderr rather than returning it.
+func HuffmanEncodeFile(inputPath, outputPath string) {
+	if err := encodeFile(inputPath, outputPath); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+}
+
+// HuffmanDecodeFile decompresses inputPath to outputPath, logging any error
+// to stderr rather than returning it.
+func HuffmanDecodeFile(inputPath, outputPath string) {
+	if err := decodeFile(inputPath, outputPath); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+}
+
+func encodeFile(inputPath, outputPath string) error {
+	inFile, err := os.Open(inputPath)
+	if err != nil {
+		return fmt.Errorf("无法打开输入文件用于读取: %s: %w", inputPath, err)
+	}
+	defer inFile.Close()
+	outFile, err := os.Create(outputPath)
+	if err != nil {
+		return fmt.Errorf("无法打开输出文件用于写入: %s: %w", outputPath, err)
+	}
+	defer outFile.Close()
+	return Encode(outFile, inFile)
+}
+
+func decodeFile(inputPath, outputPath string) error {
+	inFile, err := os.Open(inputPath)
+	if err != nil {
+		return fmt.Errorf("无法打开输入文件用于读取: %s: %w", inputPath, err)
+	}
+	defer inFile.Close()
+	outFile, err := os.Create(outputPath)
+	if err != nil {
+		return fmt.Errorf("无法打开输出文件用于写入: %s: %w", outputPath, err)
+	}
+	defer outFile.Close()
+	return Decode(outFile, inFile)
+}
+
+// Codec adapts this package's Encode/Decode to the codec.Codec interface
+// used by package container and the encoding CLI.
+type Codec struct{}
+
+func (Codec) Encode(w io.Writer, r io.Reader) error { return Encode(w, r) }
+func (Codec) Decode(w io.Writer, r io.Reader) error { return Decode(w, r) }
+func (Codec) Name() string                          { return "huffman" }
+
+func init() {
+	codec.Register(codec.IDHuffman, Codec{})
+}
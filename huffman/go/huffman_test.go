@@ -0,0 +1,150 @@
+package huffman
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func makeSeededData(n int, seed int64) []byte {
+	b := make([]byte, n)
+	r := rand.New(rand.NewSource(seed))
+	if n > 0 {
+		_, _ = r.Read(b)
+	}
+	return b
+}
+
+func roundTripStream(t *testing.T, data []byte) {
+	t.Helper()
+
+	var encoded bytes.Buffer
+	if err := Encode(&encoded, bytes.NewReader(data)); err != nil {
+		t.Fatalf("encode error: %v", err)
+	}
+	var decoded bytes.Buffer
+	if err := Decode(&decoded, bytes.NewReader(encoded.Bytes())); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if !bytes.Equal(decoded.Bytes(), data) {
+		t.Fatalf("mismatch: decoded %d bytes differ from the original %d bytes", decoded.Len(), len(data))
+	}
+
+	appended := AppendEncoded(nil, data)
+	into, err := DecodeInto(nil, appended)
+	if err != nil {
+		t.Fatalf("DecodeInto error: %v", err)
+	}
+	if !bytes.Equal(into, data) {
+		t.Fatalf("AppendEncoded/DecodeInto mismatch: got %d bytes, want %d", len(into), len(data))
+	}
+}
+
+// TestRoundTrip covers empty input, a single repeated byte (degenerate
+// one-leaf tree), and random data of several sizes and seeds, through both
+// the io.Writer/io.Reader and the []byte-append API.
+func TestRoundTrip(t *testing.T) {
+	cases := map[string][]byte{
+		"empty":    {},
+		"one byte": {0x5A},
+		"all same": bytes.Repeat([]byte{0x2A}, 10000),
+	}
+	for name, data := range cases {
+		t.Run(name, func(t *testing.T) {
+			roundTripStream(t, data)
+		})
+	}
+
+	for _, n := range []int{0, 1, 2, 100, 1000, 65536} {
+		for _, seed := range []int64{1, 2, 3} {
+			data := makeSeededData(n, seed)
+			roundTripStream(t, data)
+		}
+	}
+}
+
+// fibonacciFrequencies returns one frequency per symbol following the
+// Fibonacci sequence, the classic worst case for an unbounded Huffman tree:
+// with n leaves it drives the deepest code to depth n-1, forcing
+// codeLengths to fall back to the package-merge length-limiting pass. Terms
+// past the 47th overflow uint32 and wrap, so only the first 47 symbols follow
+// the sequence exactly.
+func fibonacciFrequencies(n int) []uint32 {
+	freq := make([]uint32, SymbolLimit)
+	a, b := uint32(1), uint32(1)
+	for i := 0; i < n; i++ {
+		freq[i] = a
+		a, b = b, a+b
+	}
+	return freq
+}
+
+// TestPackageMergeLengthLimiting feeds codeLengths a Fibonacci frequency
+// distribution over enough symbols that the naive Huffman tree would exceed
+// maxCodeLength, then checks the package-merge fallback produces a valid
+// canonical code (every length within bound, round-trips through
+// AppendEncoded/DecodeInto).
+func TestPackageMergeLengthLimiting(t *testing.T) {
+	freq := fibonacciFrequencies(SymbolLimit - 1)
+	freq[EOFSymbol] = 1
+
+	lengths := codeLengths(freq)
+	longest := 0
+	for _, l := range lengths {
+		if l > longest {
+			longest = l
+		}
+		if l > maxCodeLength {
+			t.Fatalf("length %d exceeds maxCodeLength %d", l, maxCodeLength)
+		}
+	}
+	if longest != maxCodeLength {
+		t.Fatalf("expected package-merge to produce a code reaching maxCodeLength (%d), longest was %d", maxCodeLength, longest)
+	}
+
+	if _, err := canonicalCodes(lengths); err != nil {
+		t.Fatalf("canonicalCodes rejected package-merge output: %v", err)
+	}
+
+	// Build data whose histogram follows the skewed distribution above, capped
+	// at 200 occurrences per symbol; its plain Huffman tree is still deeper
+	// than maxCodeLength, so the round trip runs on length-limited codes.
+	var data []byte
+	for s, f := range freq[:SymbolLimit-1] {
+		for i := uint32(0); i < f && i < 200; i++ {
+			data = append(data, byte(s))
+		}
+	}
+	roundTripStream(t, data)
+}
+
+// TestDecodeTruncatedDoesNotHang checks that cutting the bitstream short
+// returns (with or without an error) instead of spinning forever: before
+// Decode/DecodeInto were bounded by the declared raw length, a truncated
+// stream's zero-padded tail could keep producing non-EOF table entries
+// indefinitely.
+func TestDecodeTruncatedDoesNotHang(t *testing.T) {
+	data := []byte("hello world, this is a reasonably long test string for huffman")
+	encoded := AppendEncoded(nil, data)
+
+	truncated := encoded[:len(encoded)-len(data)/2]
+	done := make(chan struct{})
+	go func() {
+		DecodeInto(nil, truncated)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("DecodeInto hung on a truncated payload instead of returning")
+	}
+}
+
+// TestDecodeBadMagicReturnsError checks the magic-prefix guard rejects
+// unrelated input instead of panicking.
+func TestDecodeBadMagicReturnsError(t *testing.T) {
+	if _, err := DecodeInto(nil, []byte("not a huffman stream")); err == nil {
+		t.Fatal("expected an error for bad magic, got nil")
+	}
+}
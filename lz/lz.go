@@ -0,0 +1,275 @@
+// Package lz implements a configurable LZ77 match finder: a hash-chain
+// search over a sliding window with optional one-step lazy matching. It
+// parses a byte stream into Sequences — a run of literals followed by an
+// optional back-reference — which packages lzhuffman and lzrange turn into
+// compressed frames by entropy-coding the literal, length, and distance
+// alphabets separately.
+package lz
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+const (
+	// MinMatch is the shortest back-reference this package will emit;
+	// shorter repeats cost more to encode than to leave as literals.
+	MinMatch = 3
+
+	// DefaultWindowSize matches the low end of DEFLATE's window.
+	DefaultWindowSize = 32 * 1024
+
+	// MaxWindowSize bounds Config.WindowSize; distances beyond 1 MiB
+	// stop paying for themselves on typical text corpora.
+	MaxWindowSize = 1024 * 1024
+
+	// DefaultMaxMatch caps a single match's length, same as DEFLATE.
+	DefaultMaxMatch = 258
+
+	hashBits = 15
+	hashSize = 1 << hashBits
+	noPos    = -1
+)
+
+// Config tunes the match finder's speed/ratio trade-off.
+type Config struct {
+	// WindowSize bounds how far back a match may reference.
+	WindowSize int
+	// MaxMatch bounds how long a single match may run.
+	MaxMatch int
+	// MaxChainLength bounds how many candidate positions the hash chain
+	// search visits per byte; higher finds better matches more slowly.
+	MaxChainLength int
+	// Lazy enables one-step lazy matching: before taking a match at i,
+	// check whether i+1 has a strictly longer one and prefer it.
+	Lazy bool
+}
+
+// DefaultConfig is tuned for a reasonable ratio/speed balance on text.
+func DefaultConfig() Config {
+	return Config{
+		WindowSize:     DefaultWindowSize,
+		MaxMatch:       DefaultMaxMatch,
+		MaxChainLength: 128,
+		Lazy:           true,
+	}
+}
+
+// Sequence is one LZ77 parse step: a run of literal bytes, optionally
+// followed by a back-reference match. MatchLen == 0 marks the final
+// sequence of a parse, whose Literals are the unmatched tail of the input.
+type Sequence struct {
+	Literals  []byte
+	MatchLen  uint32
+	MatchDist uint32
+}
+
+// matcher holds the hash-chain index used to find back-references in data.
+type matcher struct {
+	data           []byte
+	head           []int32
+	prev           []int32
+	windowSize     int
+	maxMatch       int
+	maxChainLength int
+}
+
+func newMatcher(data []byte, cfg Config) *matcher {
+	head := make([]int32, hashSize)
+	for i := range head {
+		head[i] = noPos
+	}
+	return &matcher{
+		data:           data,
+		head:           head,
+		prev:           make([]int32, len(data)),
+		windowSize:     cfg.WindowSize,
+		maxMatch:       cfg.MaxMatch,
+		maxChainLength: cfg.MaxChainLength,
+	}
+}
+
+func hash3(data []byte, i int) uint32 {
+	v := uint32(data[i]) | uint32(data[i+1])<<8 | uint32(data[i+2])<<16
+	return (v * 2654435761) >> (32 - hashBits)
+}
+
+// insert records position i in the hash chain for the 3 bytes starting there.
+func (m *matcher) insert(i int) {
+	if i+3 > len(m.data) {
+		return
+	}
+	h := hash3(m.data, i)
+	m.prev[i] = m.head[h]
+	m.head[h] = int32(i)
+}
+
+// findMatch returns the longest match at i (length >= MinMatch) and its
+// distance, or ok == false if none qualifies.
+func (m *matcher) findMatch(i int) (length, distance int, ok bool) {
+	if i+MinMatch > len(m.data) {
+		return 0, 0, false
+	}
+	h := hash3(m.data, i)
+	minPos := i - m.windowSize
+	maxLen := len(m.data) - i
+	if maxLen > m.maxMatch {
+		maxLen = m.maxMatch
+	}
+
+	bestLen := MinMatch - 1
+	bestDist := 0
+	chain := 0
+	for p := m.head[h]; p != noPos && int(p) >= minPos; p = m.prev[p] {
+		candidate := int(p)
+		l := matchLength(m.data, candidate, i, maxLen)
+		if l > bestLen {
+			bestLen = l
+			bestDist = i - candidate
+			if l >= maxLen {
+				break
+			}
+		}
+		chain++
+		if chain >= m.maxChainLength {
+			break
+		}
+	}
+	if bestLen < MinMatch {
+		return 0, 0, false
+	}
+	return bestLen, bestDist, true
+}
+
+func matchLength(data []byte, a, b, max int) int {
+	n := 0
+	for n < max && data[a+n] == data[b+n] {
+		n++
+	}
+	return n
+}
+
+// Parse runs the match finder over data and returns its Sequence stream.
+func Parse(data []byte, cfg Config) []Sequence {
+	if cfg.WindowSize <= 0 || cfg.WindowSize > MaxWindowSize {
+		cfg.WindowSize = DefaultWindowSize
+	}
+	if cfg.MaxMatch <= 0 {
+		cfg.MaxMatch = DefaultMaxMatch
+	}
+	if cfg.MaxChainLength <= 0 {
+		cfg.MaxChainLength = 1
+	}
+
+	var sequences []Sequence
+	m := newMatcher(data, cfg)
+
+	litStart := 0
+	i := 0
+	for i < len(data) {
+		length, dist, ok := m.findMatch(i)
+		if ok && cfg.Lazy && i+1 < len(data) {
+			m.insert(i)
+			if nextLen, _, nextOK := m.findMatch(i + 1); nextOK && nextLen > length {
+				// A longer match starts one byte later: emit i as a
+				// literal for now and let the better match win instead.
+				i++
+				continue
+			}
+		} else {
+			m.insert(i)
+		}
+
+		if !ok {
+			i++
+			continue
+		}
+
+		sequences = append(sequences, Sequence{
+			Literals:  append([]byte(nil), data[litStart:i]...),
+			MatchLen:  uint32(length),
+			MatchDist: uint32(dist),
+		})
+		for j := i + 1; j < i+length && j < len(data); j++ {
+			m.insert(j)
+		}
+		i += length
+		litStart = i
+	}
+
+	sequences = append(sequences, Sequence{
+		Literals: append([]byte(nil), data[litStart:]...),
+	})
+	return sequences
+}
+
+// Reconstruct reverses Parse, replaying each Sequence's literals and
+// back-reference copy to rebuild the original byte stream.
+func Reconstruct(sequences []Sequence) []byte {
+	var out []byte
+	for _, seq := range sequences {
+		out = append(out, seq.Literals...)
+		if seq.MatchLen == 0 {
+			continue
+		}
+		start := len(out) - int(seq.MatchDist)
+		for j := 0; j < int(seq.MatchLen); j++ {
+			out = append(out, out[start+j])
+		}
+	}
+	return out
+}
+
+// SplitStreams serializes sequences into three independent streams so a
+// glue package can entropy-code each alphabet on its own, the way DEFLATE
+// keeps literal/length codes separate from distance codes: control carries
+// each sequence's literal-run length and match length, literals carries
+// the concatenated literal bytes, and distances carries match distances.
+func SplitStreams(sequences []Sequence) (control, literals, distances []byte) {
+	for _, seq := range sequences {
+		control = binary.AppendUvarint(control, uint64(len(seq.Literals)))
+		literals = append(literals, seq.Literals...)
+		control = binary.AppendUvarint(control, uint64(seq.MatchLen))
+		if seq.MatchLen > 0 {
+			distances = binary.AppendUvarint(distances, uint64(seq.MatchDist))
+		}
+	}
+	return control, literals, distances
+}
+
+// JoinStreams reverses SplitStreams, rebuilding the Sequence stream a glue
+// package's Decode can pass to Reconstruct.
+func JoinStreams(control, literals, distances []byte) ([]Sequence, error) {
+	var sequences []Sequence
+	cpos, lpos, dpos := 0, 0, 0
+	for cpos < len(control) {
+		nLit, sz := binary.Uvarint(control[cpos:])
+		if sz <= 0 {
+			return nil, errors.New("lz: control 流损坏")
+		}
+		cpos += sz
+		if lpos+int(nLit) > len(literals) {
+			return nil, errors.New("lz: literal 流截断")
+		}
+		lits := literals[lpos : lpos+int(nLit)]
+		lpos += int(nLit)
+
+		matchLen, sz := binary.Uvarint(control[cpos:])
+		if sz <= 0 {
+			return nil, errors.New("lz: control 流损坏")
+		}
+		cpos += sz
+
+		seq := Sequence{Literals: lits, MatchLen: uint32(matchLen)}
+		if matchLen > 0 {
+			dist, sz := binary.Uvarint(distances[dpos:])
+			if sz <= 0 {
+				return nil, errors.New("lz: distance 流损坏")
+			}
+			dpos += sz
+			seq.MatchDist = uint32(dist)
+		}
+		sequences = append(sequences, seq)
+	}
+	return sequences, nil
+}
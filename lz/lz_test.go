@@ -0,0 +1,95 @@
+package lz
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+func makeSeededData(n int, seed int64) []byte {
+	b := make([]byte, n)
+	r := rand.New(rand.NewSource(seed))
+	if n > 0 {
+		_, _ = r.Read(b)
+	}
+	return b
+}
+
+func makeRepetitiveData(n int) []byte {
+	const phrase = "the quick brown fox jumps over the lazy dog. "
+	var b []byte
+	for len(b) < n {
+		b = append(b, phrase...)
+	}
+	return b[:n]
+}
+
+// TestParseReconstructRoundTrip checks Parse/Reconstruct round-trip empty,
+// highly repetitive (lots of matches), and random (mostly literals) input,
+// with and without lazy matching.
+func TestParseReconstructRoundTrip(t *testing.T) {
+	cases := map[string][]byte{
+		"empty":      {},
+		"one byte":   {0x42},
+		"repetitive": makeRepetitiveData(50000),
+		"random":     makeSeededData(20000, 1),
+	}
+	for name, data := range cases {
+		for _, lazy := range []bool{true, false} {
+			cfg := DefaultConfig()
+			cfg.Lazy = lazy
+			seqs := Parse(data, cfg)
+			got := Reconstruct(seqs)
+			if !bytes.Equal(got, data) {
+				t.Fatalf("%s lazy=%v: mismatch: got %d bytes, want %d", name, lazy, len(got), len(data))
+			}
+		}
+	}
+}
+
+// TestSplitJoinStreamsRoundTrip checks that serializing a parse into the
+// three independent streams and rebuilding it reproduces the same sequence
+// of literals/matches, end to end through Reconstruct.
+func TestSplitJoinStreamsRoundTrip(t *testing.T) {
+	for _, data := range [][]byte{
+		{},
+		makeRepetitiveData(20000),
+		makeSeededData(10000, 2),
+	} {
+		seqs := Parse(data, DefaultConfig())
+		control, literals, distances := SplitStreams(seqs)
+		joined, err := JoinStreams(control, literals, distances)
+		if err != nil {
+			t.Fatalf("JoinStreams error: %v", err)
+		}
+		got := Reconstruct(joined)
+		if !bytes.Equal(got, data) {
+			t.Fatalf("mismatch: got %d bytes, want %d", len(got), len(data))
+		}
+	}
+}
+
+// TestJoinStreamsTruncatedReturnsError checks that a literal/distance stream
+// cut shorter than what control declares is reported as an error rather than
+// panicking on an out-of-range slice.
+func TestJoinStreamsTruncatedReturnsError(t *testing.T) {
+	seqs := Parse(makeRepetitiveData(5000), DefaultConfig())
+	control, literals, distances := SplitStreams(seqs)
+
+	if len(literals) > 0 {
+		if _, err := JoinStreams(control, literals[:len(literals)-1], distances); err == nil {
+			t.Fatal("expected an error for a truncated literals stream, got nil")
+		}
+	}
+	if len(distances) > 0 {
+		if _, err := JoinStreams(control, literals, distances[:len(distances)-1]); err == nil {
+			t.Fatal("expected an error for a truncated distances stream, got nil")
+		}
+	}
+	// A dangling continuation byte (high bit set, no terminating byte) is an
+	// incomplete varint that binary.Uvarint reports via sz <= 0.
+	danglingControl := append(append([]byte(nil), control...), 0x80)
+	if _, err := JoinStreams(danglingControl, literals, distances); err == nil {
+		t.Fatal("expected an error for a dangling control varint, got nil")
+	}
+}
@@ -0,0 +1,96 @@
+package lzhuffman
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+func makeSeededData(n int, seed int64) []byte {
+	b := make([]byte, n)
+	r := rand.New(rand.NewSource(seed))
+	if n > 0 {
+		_, _ = r.Read(b)
+	}
+	return b
+}
+
+func roundTrip(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var encoded bytes.Buffer
+	if err := Encode(&encoded, bytes.NewReader(data)); err != nil {
+		t.Fatalf("encode error: %v", err)
+	}
+	var decoded bytes.Buffer
+	if err := Decode(&decoded, bytes.NewReader(encoded.Bytes())); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if !bytes.Equal(decoded.Bytes(), data) {
+		t.Fatalf("mismatch: decoded %d bytes differ from the original %d bytes", decoded.Len(), len(data))
+	}
+	return encoded.Bytes()
+}
+
+// TestRoundTrip covers empty input, short input, repetitive text, and
+// random data of several sizes and seeds.
+func TestRoundTrip(t *testing.T) {
+	cases := map[string][]byte{
+		"empty": {},
+		"short": []byte("hi"),
+		"repetitive": bytes.Repeat(
+			[]byte("the quick brown fox jumps over the lazy dog. "), 500),
+	}
+	for name, data := range cases {
+		t.Run(name, func(t *testing.T) {
+			roundTrip(t, data)
+		})
+	}
+
+	for _, n := range []int{0, 1, 10, 1000, 50000} {
+		for _, seed := range []int64{1, 2} {
+			roundTrip(t, makeSeededData(n, seed))
+		}
+	}
+}
+
+// TestTinyInputDoesNotBloat checks that encoding empty or tiny input stores
+// the raw block instead of paying Huffman's fixed per-stream header three
+// times over: the framed output should stay close to the input size, not
+// balloon into the hundreds of bytes a from-scratch length table would cost.
+func TestTinyInputDoesNotBloat(t *testing.T) {
+	for _, data := range [][]byte{{}, []byte("a"), []byte("hi there")} {
+		encoded := roundTrip(t, data)
+		const maxOverhead = 32 // magic + 3 tag bytes + 3 small uvarints
+		if len(encoded) > len(data)+maxOverhead {
+			t.Fatalf("len(data)=%d: framed output is %d bytes, expected at most %d bytes of overhead",
+				len(data), len(encoded), maxOverhead)
+		}
+	}
+}
+
+// TestDecodeBadMagicReturnsError checks the magic-prefix guard.
+func TestDecodeBadMagicReturnsError(t *testing.T) {
+	var out bytes.Buffer
+	if err := Decode(&out, bytes.NewReader([]byte("not an lzhuffman stream"))); err == nil {
+		t.Fatal("expected an error for bad magic, got nil")
+	}
+}
+
+// TestDecodeTruncatedReturnsError checks that cutting a framed stream off
+// partway through is reported as an error, not a panic.
+func TestDecodeTruncatedReturnsError(t *testing.T) {
+	var encoded bytes.Buffer
+	if err := Encode(&encoded, bytes.NewReader(bytes.Repeat([]byte("abc"), 200))); err != nil {
+		t.Fatal(err)
+	}
+	full := encoded.Bytes()
+	for _, n := range []int{0, 1, 4, 5, len(full) / 2, len(full) - 1} {
+		if n < 0 || n > len(full) {
+			continue
+		}
+		var out bytes.Buffer
+		if err := Decode(&out, bytes.NewReader(full[:n])); err == nil {
+			t.Fatalf("n=%d: expected an error for a truncated stream, got nil", n)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+// Package lzrange glues package lz's match finder to the range coder: it
+// LZ77-parses the input, then range-codes the control, literal, and
+// distance streams separately, each against its own frequency model.
+package lzrange
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/LessUp/Encoding/codec"
+	"github.com/LessUp/Encoding/lz"
+	rangecoder "github.com/LessUp/Encoding/range/go"
+)
+
+// magic identifies an lzrange frame.
+var magic = [4]byte{'L', 'Z', 'R', '1'}
+
+// Encode LZ77-parses all of r and range-codes its control, literal, and
+// distance streams separately, writing the framed result to w.
+func Encode(w io.Writer, r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("读取输入失败: %w", err)
+	}
+	sequences := lz.Parse(data, lz.DefaultConfig())
+	control, literals, distances := lz.SplitStreams(sequences)
+
+	if _, err := w.Write(magic[:]); err != nil {
+		return err
+	}
+	for _, block := range [][]byte{control, literals, distances} {
+		if err := writeBlock(w, block); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Block format tags: the range coder's fixed per-stream header (magic plus
+// a 257-entry uint32 frequency table) costs over a kilobyte no matter how
+// small block is, so tiny or empty streams are stored raw instead of paying
+// that overhead three times per frame.
+const (
+	blockRaw   = 0
+	blockRange = 1
+)
+
+// writeBlock range-codes block and writes whichever of the coded or raw
+// form is smaller, length-prefixed so Decode knows where each of the three
+// streams ends.
+func writeBlock(w io.Writer, block []byte) error {
+	encoded, err := rangecoder.Encode(block)
+	if err != nil {
+		return err
+	}
+
+	tag := byte(blockRange)
+	payload := encoded
+	if len(block) < len(payload) {
+		tag, payload = blockRaw, block
+	}
+
+	if _, err := w.Write([]byte{tag}); err != nil {
+		return err
+	}
+	lenPrefix := binary.AppendUvarint(nil, uint64(len(payload)))
+	if _, err := w.Write(lenPrefix); err != nil {
+		return err
+	}
+	_, err = w.Write(payload)
+	return err
+}
+
+// readBlock reads one writeBlock-framed stream starting at pos, returning
+// the decoded bytes and the position just past it.
+func readBlock(data []byte, pos int) ([]byte, int, error) {
+	if pos >= len(data) {
+		return nil, pos, fmt.Errorf("数据块截断")
+	}
+	tag := data[pos]
+	pos++
+
+	n, sz := binary.Uvarint(data[pos:])
+	if sz <= 0 {
+		return nil, pos, fmt.Errorf("读取块长度失败")
+	}
+	pos += sz
+	if pos+int(n) > len(data) {
+		return nil, pos, fmt.Errorf("数据块截断")
+	}
+	payload := data[pos : pos+int(n)]
+	pos += int(n)
+
+	switch tag {
+	case blockRaw:
+		return append([]byte(nil), payload...), pos, nil
+	case blockRange:
+		decoded, err := rangecoder.Decode(payload)
+		if err != nil {
+			return nil, pos, err
+		}
+		return decoded, pos, nil
+	default:
+		return nil, pos, fmt.Errorf("未知的块格式标记: %d", tag)
+	}
+}
+
+// Decode reverses Encode, reading a framed stream from r and writing the
+// original bytes to w.
+func Decode(w io.Writer, r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("读取输入失败: %w", err)
+	}
+	if len(data) < 4 || [4]byte(data[:4]) != magic {
+		return fmt.Errorf("输入数据格式非法")
+	}
+
+	pos := 4
+	var blocks [3][]byte
+	for i := range blocks {
+		block, next, err := readBlock(data, pos)
+		if err != nil {
+			return err
+		}
+		blocks[i] = block
+		pos = next
+	}
+
+	sequences, err := lz.JoinStreams(blocks[0], blocks[1], blocks[2])
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(lz.Reconstruct(sequences))
+	return err
+}
+
+// Codec adapts this package's Encode/Decode to the codec.Codec interface
+// used by package container and the encoding CLI.
+type Codec struct{}
+
+func (Codec) Encode(w io.Writer, r io.Reader) error { return Encode(w, r) }
+func (Codec) Decode(w io.Writer, r io.Reader) error { return Decode(w, r) }
+func (Codec) Name() string                          { return "lzrange" }
+
+func init() {
+	codec.Register(codec.IDLZRange, Codec{})
+}
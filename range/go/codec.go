@@ -0,0 +1,30 @@
+package rangecoder
+
+import (
+	"io"
+
+	"github.com/LessUp/Encoding/codec"
+)
+
+// Codec adapts the streaming Writer/Reader to the codec.Codec interface used
+// by package container and the encoding CLI.
+type Codec struct{}
+
+func (Codec) Encode(w io.Writer, r io.Reader) error {
+	rw := NewWriter(w)
+	if _, err := io.Copy(rw, r); err != nil {
+		return err
+	}
+	return rw.Close()
+}
+
+func (Codec) Decode(w io.Writer, r io.Reader) error {
+	_, err := io.Copy(w, NewReader(r))
+	return err
+}
+
+func (Codec) Name() string { return "range" }
+
+func init() {
+	codec.Register(codec.IDRange, Codec{})
+}
@@ -0,0 +1,235 @@
+package rangecoder
+
+import "errors"
+
+// EscapeMethod selects how a PPM context estimates the probability that the
+// current symbol has never been seen in a given context (and so encoding
+// must escape to the next lower order).
+type EscapeMethod byte
+
+const (
+	// EscapeC assigns the escape count the number of distinct symbols seen
+	// in the context so far (PPMC).
+	EscapeC EscapeMethod = iota
+	// EscapeD halves that count (rounding up), which tends to waste fewer
+	// bits on the escape once a context has seen a handful of symbols
+	// (PPMD).
+	EscapeD
+)
+
+// EncodeOptions configures the order-N context model used by
+// EncodeWithOptions. Order 0 behaves like the original order-0 Encode.
+type EncodeOptions struct {
+	// Order is the number of preceding bytes used as context, 0-3.
+	Order int
+	// EscapeMethod selects the escape-probability estimator.
+	EscapeMethod EscapeMethod
+	// InitIncrement is added to a context's symbol count every time that
+	// symbol is coded through it. Defaults to 1 when zero.
+	InitIncrement uint32
+}
+
+func (o EncodeOptions) normalized() EncodeOptions {
+	if o.Order < 0 {
+		o.Order = 0
+	}
+	if o.Order > 3 {
+		o.Order = 3
+	}
+	if o.InitIncrement == 0 {
+		o.InitIncrement = 1
+	}
+	return o
+}
+
+// escapeSymbol is a virtual symbol appended after the real alphabet
+// (0-255 plus eofSymbol) meaning "not seen in this context, drop to the
+// next lower order".
+const (
+	escapeSymbol       = symbolLimit
+	contextSymbolLimit = symbolLimit + 1
+)
+
+// ctxTable is the frequency table for a single PPM context: one entry per
+// real symbol plus a trailing escape count recomputed from EscapeMethod.
+type ctxTable struct {
+	freq []uint32
+}
+
+func newCtxTable() *ctxTable {
+	return &ctxTable{freq: make([]uint32, contextSymbolLimit)}
+}
+
+func (t *ctxTable) seen() uint32 {
+	var n uint32
+	for i := 0; i < symbolLimit; i++ {
+		if t.freq[i] > 0 {
+			n++
+		}
+	}
+	return n
+}
+
+func (t *ctxTable) cumulative(method EscapeMethod) []uint32 {
+	seen := t.seen()
+	var esc uint32
+	switch method {
+	case EscapeD:
+		esc = (seen + 1) / 2
+	default:
+		esc = seen
+	}
+	if esc == 0 {
+		esc = 1
+	}
+	t.freq[escapeSymbol] = esc
+	return buildCumulative(t.freq)
+}
+
+// ppmModel holds one context table per order, keyed by the preceding bytes.
+// Encoder and decoder build identical tables by mutating them in lock-step,
+// so nothing but Order/EscapeMethod/InitIncrement needs to be transmitted.
+type ppmModel struct {
+	opts     EncodeOptions
+	tables   []map[string]*ctxTable // tables[k] holds order-k contexts
+	fallback []uint32               // uniform order(-1) cumulative, never mutated
+}
+
+func newPPMModel(opts EncodeOptions) *ppmModel {
+	m := &ppmModel{opts: opts, tables: make([]map[string]*ctxTable, opts.Order+1)}
+	for k := range m.tables {
+		m.tables[k] = make(map[string]*ctxTable)
+	}
+	uniform := make([]uint32, symbolLimit)
+	for i := range uniform {
+		uniform[i] = 1
+	}
+	m.fallback = buildCumulative(uniform)
+	return m
+}
+
+func (m *ppmModel) contextKey(history []byte, order int) string {
+	if order == 0 {
+		return ""
+	}
+	start := len(history) - order
+	if start < 0 {
+		start = 0
+	}
+	return string(history[start:])
+}
+
+func (m *ppmModel) table(order int, history []byte) *ctxTable {
+	key := m.contextKey(history, order)
+	t, ok := m.tables[order][key]
+	if !ok {
+		t = newCtxTable()
+		m.tables[order][key] = t
+	}
+	return t
+}
+
+// code runs the shared order-N -> order-0 -> order(-1) search, invoking
+// step for every context tried. step must either encode or decode exactly
+// one symbol using cum and report whether it matched a real symbol (true)
+// or hit escape (false); on the final, uniform fallback call cum has no
+// escape slot and step must always report a match.
+func (m *ppmModel) code(history []byte, step func(cum []uint32) (symbol uint32, matched bool)) (uint32, []*ctxTable) {
+	var touched []*ctxTable
+	for order := m.opts.Order; order >= 0; order-- {
+		t := m.table(order, history)
+		cum := t.cumulative(m.opts.EscapeMethod)
+		touched = append(touched, t)
+		if symbol, matched := step(cum); matched {
+			m.update(touched, symbol)
+			return symbol, touched
+		}
+	}
+	symbol, _ := step(m.fallback)
+	m.update(touched, symbol)
+	return symbol, touched
+}
+
+func (m *ppmModel) update(touched []*ctxTable, symbol uint32) {
+	for _, t := range touched {
+		t.freq[symbol] += m.opts.InitIncrement
+	}
+}
+
+// EncodeWithOptions range-codes input using an order-N PPM-lite context
+// model. Order 0 falls back to the plain order-0 format produced by Encode.
+func EncodeWithOptions(input []byte, opts EncodeOptions) ([]byte, error) {
+	opts = opts.normalized()
+	if opts.Order == 0 {
+		return Encode(input)
+	}
+
+	model := newPPMModel(opts)
+	out := make([]byte, 0, len(input))
+	out = append(out, 'R', 'C', 'P', 'M')
+	out = append(out, byte(opts.Order), byte(opts.EscapeMethod))
+	writeU32LE(&out, opts.InitIncrement)
+
+	enc := newEncoder(&out)
+	history := make([]byte, 0, len(input))
+	for _, b := range input {
+		symbol := uint32(b)
+		model.code(history, func(cum []uint32) (uint32, bool) {
+			if cum[symbol+1] == cum[symbol] {
+				// Not seen in this context: the decoder unconditionally
+				// decodes one symbol at every order it tries, so the
+				// escape has to actually be coded here, not just implied
+				// by falling through to the next lower order.
+				enc.encodeSymbol(escapeSymbol, cum)
+				return 0, false
+			}
+			enc.encodeSymbol(symbol, cum)
+			return symbol, true
+		})
+		history = append(history, b)
+	}
+	model.code(history, func(cum []uint32) (uint32, bool) {
+		if cum[eofSymbol+1] == cum[eofSymbol] {
+			enc.encodeSymbol(escapeSymbol, cum)
+			return 0, false
+		}
+		enc.encodeSymbol(eofSymbol, cum)
+		return eofSymbol, true
+	})
+	enc.finish()
+	return out, nil
+}
+
+func decodePPM(encoded []byte) ([]byte, error) {
+	if len(encoded) < 10 {
+		return nil, errors.New("range: ppm input too short")
+	}
+	order := int(encoded[4])
+	method := EscapeMethod(encoded[5])
+	pos := 6
+	initIncrement, ok := readU32LE(encoded, &pos)
+	if !ok {
+		return nil, errors.New("range: truncated ppm header")
+	}
+	opts := EncodeOptions{Order: order, EscapeMethod: method, InitIncrement: initIncrement}.normalized()
+	model := newPPMModel(opts)
+
+	dec := newDecoder(encoded[pos:])
+	out := make([]byte, 0, len(encoded))
+	history := make([]byte, 0, len(encoded))
+	for {
+		symbol, _ := model.code(history, func(cum []uint32) (uint32, bool) {
+			sym := dec.decodeSymbol(cum)
+			if sym == escapeSymbol {
+				return 0, false
+			}
+			return sym, true
+		})
+		if symbol == eofSymbol {
+			break
+		}
+		out = append(out, byte(symbol))
+		history = append(history, byte(symbol))
+	}
+	return out, nil
+}
@@ -0,0 +1,58 @@
+package rangecoder
+
+import (
+	"bytes"
+	"testing"
+)
+
+// TestPPMRoundTripText exercises EncodeWithOptions/decodePPM on ordinary
+// text for every order and escape method, since text repeats its contexts
+// and so also codes symbols directly in order-1 and higher contexts, while
+// on tiny or random inputs nearly every symbol escapes to a lower order.
+func TestPPMRoundTripText(t *testing.T) {
+	text := []byte("the quick brown fox jumps over the lazy dog. the quick brown fox runs away.")
+
+	for order := 1; order <= 3; order++ {
+		for _, method := range []EscapeMethod{EscapeC, EscapeD} {
+			opts := EncodeOptions{Order: order, EscapeMethod: method}
+			enc, err := EncodeWithOptions(text, opts)
+			if err != nil {
+				t.Fatalf("order=%d method=%v: encode error: %v", order, method, err)
+			}
+			dec, err := decodePPM(enc)
+			if err != nil {
+				t.Fatalf("order=%d method=%v: decode error: %v", order, method, err)
+			}
+			if !bytes.Equal(dec, text) {
+				t.Fatalf("order=%d method=%v: mismatch: got %q, want %q", order, method, dec, text)
+			}
+		}
+	}
+}
+
+// TestPPMRoundTripRandom covers sizes small enough that most contexts never
+// recur, forcing an escape all the way down to the order(-1) fallback.
+func TestPPMRoundTripRandom(t *testing.T) {
+	for _, n := range []int{0, 1, 5, 1000, 20000} {
+		data := makeTestData(n)
+		for order := 0; order <= 3; order++ {
+			opts := EncodeOptions{Order: order}
+			enc, err := EncodeWithOptions(data, opts)
+			if err != nil {
+				t.Fatalf("n=%d order=%d: encode error: %v", n, order, err)
+			}
+			var dec []byte
+			if order == 0 {
+				dec, err = Decode(enc)
+			} else {
+				dec, err = decodePPM(enc)
+			}
+			if err != nil {
+				t.Fatalf("n=%d order=%d: decode error: %v", n, order, err)
+			}
+			if !bytes.Equal(dec, data) {
+				t.Fatalf("n=%d order=%d: mismatch", n, order)
+			}
+		}
+	}
+}
@@ -6,21 +6,43 @@ const (
 	symbolLimit     = 257
 	eofSymbol       = symbolLimit - 1
 	maxTotal  uint32 = 1 << 24
-	renormThreshold  = uint32(1) << 24
+
+	// topValue is the renormalization floor: rangeVal is shifted left one
+	// byte at a time whenever it drops below this, keeping it >= maxTotal
+	// so rangeVal/total in encodeSymbol/decodeSymbol never underflows to 0.
+	topValue = uint32(1) << 24
 )
 
 func scaleFrequencies(freq []uint32) {
+	ScaleFrequencies(freq, maxTotal, false)
+}
+
+// ScaleFrequencies scales freq in place so its total fits the entropy
+// coder's needs, shared by this package's own range coder (target maxTotal,
+// exact false: a total already at or under target is left alone, since the
+// interval-narrowing scheme only needs an upper bound) and package fse
+// (target 1<<tableLog, exact true: every table slot must be assigned a
+// symbol, so the total must come out exactly right, scaling up small totals
+// and nudging any rounding error into the largest remaining count).
+func ScaleFrequencies(freq []uint32, target uint32, exact bool) {
 	var total uint64
 	for _, f := range freq {
 		total += uint64(f)
 	}
 	if total == 0 {
+		base := target / uint32(len(freq))
+		if base == 0 {
+			base = 1
+		}
 		for i := range freq {
-			freq[i] = 1
+			freq[i] = base
+		}
+		if exact {
+			fixRemainder(freq, target)
 		}
 		return
 	}
-	if total <= uint64(maxTotal) {
+	if !exact && total <= uint64(target) {
 		return
 	}
 	var newTotal uint64
@@ -28,7 +50,7 @@ func scaleFrequencies(freq []uint32) {
 		if f == 0 {
 			continue
 		}
-		scaled := uint64(f) * uint64(maxTotal) / total
+		scaled := uint64(f) * uint64(target) / total
 		if scaled == 0 {
 			scaled = 1
 		}
@@ -36,7 +58,7 @@ func scaleFrequencies(freq []uint32) {
 		newTotal += scaled
 	}
 	if newTotal == 0 {
-		base := maxTotal / uint32(len(freq))
+		base := target / uint32(len(freq))
 		if base == 0 {
 			base = 1
 		}
@@ -44,10 +66,62 @@ func scaleFrequencies(freq []uint32) {
 			freq[i] = base
 		}
 	}
+	if exact {
+		fixRemainder(freq, target)
+	}
+}
+
+// fixRemainder nudges counts (already close to target) to sum to exactly
+// target, growing or shrinking whichever present symbol currently has the
+// largest count.
+func fixRemainder(counts []uint32, target uint32) {
+	var sum uint32
+	for _, c := range counts {
+		sum += c
+	}
+	remainder := int64(target) - int64(sum)
+	for remainder != 0 {
+		best := -1
+		for i, c := range counts {
+			if c == 0 {
+				continue
+			}
+			if remainder > 0 {
+				if best == -1 || c > counts[best] {
+					best = i
+				}
+			} else if c > 1 && (best == -1 || c > counts[best]) {
+				best = i
+			}
+		}
+		if best == -1 {
+			break
+		}
+		if remainder > 0 {
+			counts[best]++
+			remainder--
+		} else {
+			counts[best]--
+			remainder++
+		}
+	}
 }
 
 func buildFrequencies(data []byte) []uint32 {
-	freq := make([]uint32, symbolLimit)
+	return buildFrequenciesInto(nil, data)
+}
+
+// buildFrequenciesInto behaves like buildFrequencies but reuses freq's
+// backing array when it already has room for symbolLimit entries.
+func buildFrequenciesInto(freq []uint32, data []byte) []uint32 {
+	if cap(freq) < symbolLimit {
+		freq = make([]uint32, symbolLimit)
+	} else {
+		freq = freq[:symbolLimit]
+		for i := range freq {
+			freq[i] = 0
+		}
+	}
 	for _, b := range data {
 		freq[int(b)]++
 	}
@@ -57,7 +131,19 @@ func buildFrequencies(data []byte) []uint32 {
 }
 
 func buildCumulative(freq []uint32) []uint32 {
-	cum := make([]uint32, len(freq)+1)
+	return buildCumulativeInto(nil, freq)
+}
+
+// buildCumulativeInto behaves like buildCumulative but reuses cum's backing
+// array when it already has room for len(freq)+1 entries.
+func buildCumulativeInto(cum []uint32, freq []uint32) []uint32 {
+	n := len(freq) + 1
+	if cap(cum) < n {
+		cum = make([]uint32, n)
+	} else {
+		cum = cum[:n]
+		cum[0] = 0
+	}
 	for i, f := range freq {
 		cum[i+1] = cum[i] + f
 	}
@@ -69,6 +155,17 @@ func buildCumulative(freq []uint32) []uint32 {
 	return cum
 }
 
+// grow returns dst with at least extra bytes of spare capacity, copying it
+// to a larger backing array only when necessary.
+func grow(dst []byte, extra int) []byte {
+	if cap(dst)-len(dst) >= extra {
+		return dst
+	}
+	grown := make([]byte, len(dst), len(dst)+extra)
+	copy(grown, dst)
+	return grown
+}
+
 func writeU32LE(out *[]byte, v uint32) {
 	*out = append(*out,
 		byte(v&0xFF),
@@ -92,6 +189,12 @@ func readU32LE(in []byte, pos *int) (uint32, bool) {
 
 func writeHeader(out *[]byte, freq []uint32) {
 	*out = append(*out, 'R', 'C', 'N', 'C')
+	writeFreqTable(out, freq)
+}
+
+// writeFreqTable appends a frequency table (count prefix + LE uint32 values)
+// without any magic, so block frames can embed it inline.
+func writeFreqTable(out *[]byte, freq []uint32) {
 	writeU32LE(out, uint32(len(freq)))
 	for _, v := range freq {
 		writeU32LE(out, v)
@@ -106,11 +209,26 @@ func readHeader(in []byte, pos *int) ([]uint32, error) {
 		return nil, errors.New("range: bad magic")
 	}
 	*pos = 4
+	return readFreqTable(in, pos)
+}
+
+// readFreqTable reads a frequency table written by writeFreqTable.
+func readFreqTable(in []byte, pos *int) ([]uint32, error) {
+	return readFreqTableInto(nil, in, pos)
+}
+
+// readFreqTableInto behaves like readFreqTable but reuses freq's backing
+// array when it already has room for the transmitted table.
+func readFreqTableInto(freq []uint32, in []byte, pos *int) ([]uint32, error) {
 	count, ok := readU32LE(in, pos)
 	if !ok || count == 0 || count > 1024 {
 		return nil, errors.New("range: bad header")
 	}
-	freq := make([]uint32, count)
+	if cap(freq) < int(count) {
+		freq = make([]uint32, count)
+	} else {
+		freq = freq[:count]
+	}
 	for i := uint32(0); i < count; i++ {
 		v, ok := readU32LE(in, pos)
 		if !ok {
@@ -121,54 +239,79 @@ func readHeader(in []byte, pos *int) ([]uint32, error) {
 	return freq, nil
 }
 
+// encoder and decoder implement the carry-propagating range coder used by
+// 7-Zip's LZMA (low kept in 33 bits via uint64, a one-byte cache plus a
+// pending-0xFF run absorb carries out of the top byte). The previous
+// low/high-interval formulation here had no carry handling at all: once
+// low and high converged near a 0x..FF/0x..00 boundary, a carry out of low
+// could silently fail to propagate into already-emitted bytes, corrupting
+// or hanging the decode. This scheme sidesteps that by never emitting a
+// byte until it's known final.
 type encoder struct {
-	low  uint32
-	high uint32
-	out  *[]byte
+	low       uint64
+	rangeVal  uint32
+	cache     byte
+	cacheSize uint64
+	out       *[]byte
 }
 
 func newEncoder(out *[]byte) *encoder {
-	return &encoder{low: 0, high: 0xFFFFFFFF, out: out}
+	return &encoder{low: 0, rangeVal: 0xFFFFFFFF, cacheSize: 1, cache: 0, out: out}
+}
+
+// shiftLow emits the top byte of low once no further carry can reach it,
+// propagating a pending carry into any buffered run of 0xFF bytes first.
+func (e *encoder) shiftLow() {
+	if uint32(e.low>>32) != 0 || e.low < 0xFF000000 {
+		carry := byte(e.low >> 32)
+		temp := e.cache
+		for {
+			*e.out = append(*e.out, temp+carry)
+			temp = 0xFF
+			e.cacheSize--
+			if e.cacheSize == 0 {
+				break
+			}
+		}
+		e.cache = byte(e.low >> 24)
+	}
+	e.cacheSize++
+	e.low = (e.low & 0x00FFFFFF) << 8
 }
 
 func (e *encoder) encodeSymbol(symbol uint32, cumulative []uint32) {
-	rangeVal := uint64(e.high) - uint64(e.low) + 1
-	total := uint64(cumulative[len(cumulative)-1])
-	symLow := uint64(cumulative[symbol])
-	symHigh := uint64(cumulative[symbol+1])
+	total := cumulative[len(cumulative)-1]
+	symLow := cumulative[symbol]
+	symHigh := cumulative[symbol+1]
 
-	e.high = e.low + uint32((rangeVal*symHigh)/total-1)
-	e.low = e.low + uint32((rangeVal*symLow)/total)
+	r := e.rangeVal / total
+	e.low += uint64(symLow) * uint64(r)
+	e.rangeVal = r * (symHigh - symLow)
 
-	for (e.low ^ e.high) < renormThreshold {
-		b := byte(e.low >> 24)
-		*e.out = append(*e.out, b)
-		e.low <<= 8
-		e.high = (e.high << 8) | 0xFF
+	for e.rangeVal < topValue {
+		e.rangeVal <<= 8
+		e.shiftLow()
 	}
 }
 
 func (e *encoder) finish() {
-	for i := 0; i < 4; i++ {
-		b := byte(e.low >> 24)
-		*e.out = append(*e.out, b)
-		e.low <<= 8
+	for i := 0; i < 5; i++ {
+		e.shiftLow()
 	}
 }
 
 type decoder struct {
-	low  uint32
-	high uint32
-	code uint32
-	in   []byte
-	pos  int
+	rangeVal uint32
+	code     uint32
+	in       []byte
+	pos      int
 }
 
 func newDecoder(in []byte) *decoder {
-	d := &decoder{low: 0, high: 0xFFFFFFFF, in: in}
+	d := &decoder{rangeVal: 0xFFFFFFFF, in: in}
+	d.readByte() // the encoder's first emitted byte is always the initial cache (0); discard it
 	for i := 0; i < 4; i++ {
-		b := d.readByte()
-		d.code = (d.code << 8) | uint32(b)
+		d.code = (d.code << 8) | uint32(d.readByte())
 	}
 	return d
 }
@@ -183,16 +326,18 @@ func (d *decoder) readByte() byte {
 }
 
 func (d *decoder) decodeSymbol(cumulative []uint32) uint32 {
-	rangeVal := uint64(d.high) - uint64(d.low) + 1
-	total := uint64(cumulative[len(cumulative)-1])
-	offset := uint64(d.code - d.low)
-	value := ((offset+1)*total - 1) / rangeVal
+	total := cumulative[len(cumulative)-1]
+	d.rangeVal /= total
+	value := d.code / d.rangeVal
+	if value >= total {
+		value = total - 1
+	}
 
 	lo := uint32(0)
 	hi := uint32(len(cumulative) - 1)
 	for lo+1 < hi {
 		mid := lo + (hi-lo)/2
-		if uint64(cumulative[mid]) > value {
+		if cumulative[mid] > value {
 			hi = mid
 		} else {
 			lo = mid
@@ -200,60 +345,152 @@ func (d *decoder) decodeSymbol(cumulative []uint32) uint32 {
 	}
 	symbol := lo
 
-	symLow := uint64(cumulative[symbol])
-	symHigh := uint64(cumulative[symbol+1])
+	symLow := cumulative[symbol]
+	symHigh := cumulative[symbol+1]
 
-	d.high = d.low + uint32((rangeVal*symHigh)/total-1)
-	d.low = d.low + uint32((rangeVal*symLow)/total)
+	d.code -= symLow * d.rangeVal
+	d.rangeVal *= symHigh - symLow
 
-	for (d.low ^ d.high) < renormThreshold {
-		d.low <<= 8
-		d.high = (d.high << 8) | 0xFF
+	for d.rangeVal < topValue {
 		d.code = (d.code << 8) | uint32(d.readByte())
+		d.rangeVal <<= 8
 	}
 
 	return symbol
 }
 
+// headerSize bounds the static cost AppendEncoded adds on top of len(src):
+// the 4-byte magic, the frequency table's 4-byte count prefix, and one
+// uint32 per symbol.
+const headerSize = 4 + 4 + symbolLimit*4
+
+// Encode range-codes input, same as AppendEncoded(nil, input).
 func Encode(input []byte) ([]byte, error) {
-	freq := buildFrequencies(input)
-	cum := buildCumulative(freq)
+	return AppendEncoded(nil, input), nil
+}
 
-	out := make([]byte, 0, len(input))
-	writeHeader(&out, freq)
+// AppendEncoded range-codes src and appends the framed result to dst,
+// growing dst once up front by headerSize+len(src)+16 bytes. Output longer
+// than that estimate (as on large random input) grows dst again through
+// append.
+func AppendEncoded(dst, src []byte) []byte {
+	dst = grow(dst, headerSize+len(src)+16)
+	freq := buildFrequencies(src)
+	cum := buildCumulative(freq)
 
-	enc := newEncoder(&out)
-	for _, b := range input {
+	writeHeader(&dst, freq)
+	enc := newEncoder(&dst)
+	for _, b := range src {
 		enc.encodeSymbol(uint32(b), cum)
 	}
 	enc.encodeSymbol(eofSymbol, cum)
 	enc.finish()
-
-	return out, nil
+	return dst
 }
 
+// Decode reverses Encode, same as DecodeInto(nil, encoded).
 func Decode(encoded []byte) ([]byte, error) {
+	return DecodeInto(nil, encoded)
+}
+
+// DecodeInto reverses AppendEncoded, appending the decompressed bytes to
+// dst and returning the grown slice.
+func DecodeInto(dst, src []byte) ([]byte, error) {
+	if len(src) >= 4 && src[0] == 'R' && src[1] == 'C' && src[2] == 'P' && src[3] == 'M' {
+		decoded, err := decodePPM(src)
+		if err != nil {
+			return dst, err
+		}
+		return append(dst, decoded...), nil
+	}
 	pos := 0
-	freq, err := readHeader(encoded, &pos)
+	freq, err := readHeader(src, &pos)
 	if err != nil {
-		return nil, err
+		return dst, err
 	}
 	if len(freq) != symbolLimit {
-		return nil, errors.New("range: unexpected symbol count")
+		return dst, errors.New("range: unexpected symbol count")
 	}
 	cum := buildCumulative(freq)
-	if pos >= len(encoded) {
-		return []byte{}, nil
+	if pos >= len(src) {
+		return dst, nil
 	}
 
-	dec := newDecoder(encoded[pos:])
-	out := make([]byte, 0, len(encoded))
+	dec := newDecoder(src[pos:])
 	for {
 		sym := dec.decodeSymbol(cum)
 		if sym == uint32(eofSymbol) {
 			break
 		}
-		out = append(out, byte(sym))
+		dst = append(dst, byte(sym))
+	}
+	return dst, nil
+}
+
+// Coder reuses its frequency and cumulative-frequency buffers across calls,
+// avoiding the make([]uint32, symbolLimit) and make([]uint32, symbolLimit+1)
+// allocations that AppendEncoded and DecodeInto otherwise repeat every
+// call. A Coder is not safe for concurrent use; give each goroutine its own.
+type Coder struct {
+	freq []uint32
+	cum  []uint32
+}
+
+// AppendEncoded behaves like the package-level AppendEncoded but reuses c's
+// buffers instead of allocating new ones.
+func (c *Coder) AppendEncoded(dst, src []byte) []byte {
+	dst = grow(dst, headerSize+len(src)+16)
+	c.freq = buildFrequenciesInto(c.freq, src)
+	c.cum = buildCumulativeInto(c.cum, c.freq)
+
+	writeHeader(&dst, c.freq)
+	enc := newEncoder(&dst)
+	for _, b := range src {
+		enc.encodeSymbol(uint32(b), c.cum)
+	}
+	enc.encodeSymbol(eofSymbol, c.cum)
+	enc.finish()
+	return dst
+}
+
+// DecodeInto behaves like the package-level DecodeInto but reuses c's
+// buffers instead of allocating new ones. PPM streams fall back to the
+// unpooled decodePPM, since context tables aren't poolable the same way.
+func (c *Coder) DecodeInto(dst, src []byte) ([]byte, error) {
+	if len(src) >= 4 && src[0] == 'R' && src[1] == 'C' && src[2] == 'P' && src[3] == 'M' {
+		decoded, err := decodePPM(src)
+		if err != nil {
+			return dst, err
+		}
+		return append(dst, decoded...), nil
+	}
+	if len(src) < 8 {
+		return dst, errors.New("range: input too short")
+	}
+	if src[0] != 'R' || src[1] != 'C' || src[2] != 'N' || src[3] != 'C' {
+		return dst, errors.New("range: bad magic")
+	}
+	pos := 4
+	freq, err := readFreqTableInto(c.freq, src, &pos)
+	if err != nil {
+		return dst, err
+	}
+	c.freq = freq
+	if len(freq) != symbolLimit {
+		return dst, errors.New("range: unexpected symbol count")
+	}
+	c.cum = buildCumulativeInto(c.cum, freq)
+	if pos >= len(src) {
+		return dst, nil
+	}
+
+	dec := newDecoder(src[pos:])
+	for {
+		sym := dec.decodeSymbol(c.cum)
+		if sym == uint32(eofSymbol) {
+			break
+		}
+		dst = append(dst, byte(sym))
 	}
-	return out, nil
+	return dst, nil
 }
@@ -64,6 +64,24 @@ func BenchmarkEncodeDecode1MiB(b *testing.B) {
 	}
 }
 
+func BenchmarkCoderEncodeDecode1MiB(b *testing.B) {
+	data := makeTestData(1 << 20)
+	var c Coder
+	var encDst, decDst []byte
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		encDst = c.AppendEncoded(encDst[:0], data)
+		var err error
+		decDst, err = c.DecodeInto(decDst[:0], encDst)
+		if err != nil {
+			b.Fatalf("decode error: %v", err)
+		}
+		if len(decDst) != len(data) {
+			b.Fatalf("length mismatch: got %d, want %d", len(decDst), len(data))
+		}
+	}
+}
+
 func BenchmarkEncodeDecode4MiB(b *testing.B) {
 	data := makeTestData(4 << 20)
 	b.ResetTimer()
@@ -82,6 +100,39 @@ func BenchmarkEncodeDecode4MiB(b *testing.B) {
 	}
 }
 
+func TestCoderRoundTrip(t *testing.T) {
+	var c Coder
+	var encDst, decDst []byte
+	for _, n := range []int{0, 1, 100, 10000} {
+		data := makeTestData(n)
+		var err error
+		encDst = c.AppendEncoded(encDst[:0], data)
+		decDst, err = c.DecodeInto(decDst[:0], encDst)
+		if err != nil {
+			t.Fatalf("n=%d decode error: %v", n, err)
+		}
+		if !bytes.Equal(decDst, data) {
+			t.Fatalf("n=%d mismatch: decoded data differs from original", n)
+		}
+	}
+}
+
+func TestAppendEncodedPreservesPrefix(t *testing.T) {
+	data := makeTestData(500)
+	prefix := []byte("prefix:")
+	dst := AppendEncoded(append([]byte(nil), prefix...), data)
+	if !bytes.HasPrefix(dst, prefix) {
+		t.Fatalf("AppendEncoded clobbered dst's existing prefix")
+	}
+	dec, err := DecodeInto(nil, dst[len(prefix):])
+	if err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if !bytes.Equal(dec, data) {
+		t.Fatalf("mismatch: decoded data differs from original")
+	}
+}
+
 func TestDeterministic(t *testing.T) {
 	data := makeTestData(1 << 16)
 	enc1, err := Encode(data)
@@ -0,0 +1,341 @@
+package rangecoder
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// Mode selects how a streaming Writer/Reader models symbol frequencies.
+type Mode byte
+
+const (
+	// ModeStatic scans each block up front and writes its frequency table
+	// in the block header, same as the one-shot Encode format.
+	ModeStatic Mode = iota
+	// ModeAdaptive starts from a uniform model and updates it after every
+	// symbol, so no frequency table needs to be transmitted at all.
+	ModeAdaptive
+)
+
+const (
+	defaultBlockSize = 64 * 1024
+
+	streamMagic = "RCST"
+	blockMagic  = "RCBK"
+	endMagic    = "RCEF"
+)
+
+// WriterOptions configures a streaming Writer.
+type WriterOptions struct {
+	// Mode selects static (per-block frequency table) or adaptive modelling.
+	Mode Mode
+	// BlockSize is the number of raw bytes coded per block. Defaults to
+	// defaultBlockSize when zero.
+	BlockSize int
+}
+
+// Writer range-codes bytes written to it into framed blocks written to the
+// underlying io.Writer. It must be closed to flush the final partial block.
+type Writer struct {
+	w     io.Writer
+	opts  WriterOptions
+	buf   []byte
+	model *adaptiveModel
+	wrote bool
+	err   error
+}
+
+// NewWriter returns a Writer using static per-block frequency tables and the
+// default block size, matching the shape of gzip.NewWriter.
+func NewWriter(w io.Writer) *Writer {
+	return NewWriterOptions(w, WriterOptions{Mode: ModeStatic, BlockSize: defaultBlockSize})
+}
+
+// NewWriterOptions returns a Writer configured by opts.
+func NewWriterOptions(w io.Writer, opts WriterOptions) *Writer {
+	if opts.BlockSize <= 0 {
+		opts.BlockSize = defaultBlockSize
+	}
+	wr := &Writer{w: w, opts: opts}
+	if opts.Mode == ModeAdaptive {
+		wr.model = newAdaptiveModel()
+	}
+	return wr
+}
+
+func (w *Writer) writeStreamHeader() error {
+	if w.wrote {
+		return nil
+	}
+	head := make([]byte, 0, 9)
+	head = append(head, streamMagic...)
+	head = append(head, byte(w.opts.Mode))
+	writeU32LE(&head, uint32(w.opts.BlockSize))
+	if _, err := w.w.Write(head); err != nil {
+		return err
+	}
+	w.wrote = true
+	return nil
+}
+
+// Write buffers p and range-codes it in BlockSize chunks as they fill.
+func (w *Writer) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	if err := w.writeStreamHeader(); err != nil {
+		w.err = err
+		return 0, err
+	}
+	w.buf = append(w.buf, p...)
+	for len(w.buf) >= w.opts.BlockSize {
+		if err := w.flushBlock(w.buf[:w.opts.BlockSize]); err != nil {
+			w.err = err
+			return 0, err
+		}
+		w.buf = append([]byte(nil), w.buf[w.opts.BlockSize:]...)
+	}
+	return len(p), nil
+}
+
+func (w *Writer) flushBlock(block []byte) error {
+	if w.opts.Mode == ModeAdaptive {
+		return w.flushAdaptiveBlock(block)
+	}
+	freq := buildFrequencies(block)
+	cum := buildCumulative(freq)
+	var payload []byte
+	enc := newEncoder(&payload)
+	for _, b := range block {
+		enc.encodeSymbol(uint32(b), cum)
+	}
+	enc.finish()
+	return writeBlockFrame(w.w, uint32(len(block)), freq, payload)
+}
+
+func (w *Writer) flushAdaptiveBlock(block []byte) error {
+	var payload []byte
+	enc := newEncoder(&payload)
+	for _, b := range block {
+		sym := uint32(b)
+		enc.encodeSymbol(sym, w.model.cum)
+		w.model.update(sym)
+	}
+	enc.finish()
+	return writeBlockFrame(w.w, uint32(len(block)), nil, payload)
+}
+
+// writeBlockFrame writes magic, raw length, an optional frequency table
+// (static mode only), compressed length, then the coded payload.
+func writeBlockFrame(w io.Writer, rawLen uint32, freq []uint32, payload []byte) error {
+	head := make([]byte, 0, 16+4*len(freq))
+	head = append(head, blockMagic...)
+	writeU32LE(&head, rawLen)
+	if freq != nil {
+		writeFreqTable(&head, freq)
+	}
+	writeU32LE(&head, uint32(len(payload)))
+	if _, err := w.Write(head); err != nil {
+		return err
+	}
+	_, err := w.Write(payload)
+	return err
+}
+
+// Close flushes any buffered partial block and writes the end-of-stream
+// marker. The Writer must not be used afterwards.
+func (w *Writer) Close() error {
+	if w.err != nil {
+		return w.err
+	}
+	if err := w.writeStreamHeader(); err != nil {
+		return err
+	}
+	if len(w.buf) > 0 {
+		if err := w.flushBlock(w.buf); err != nil {
+			w.err = err
+			return err
+		}
+		w.buf = nil
+	}
+	_, err := w.w.Write([]byte(endMagic))
+	return err
+}
+
+// Reader decodes a block stream produced by Writer.
+type Reader struct {
+	r       io.Reader
+	opts    WriterOptions
+	model   *adaptiveModel
+	pending []byte
+	read    bool
+	eof     bool
+	err     error
+}
+
+// NewReader returns a Reader that decodes the framed block stream read
+// from r, matching the shape of gzip.NewReader.
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: r}
+}
+
+func (r *Reader) readStreamHeader() error {
+	if r.read {
+		return nil
+	}
+	head := make([]byte, 9)
+	if _, err := io.ReadFull(r.r, head); err != nil {
+		return err
+	}
+	if string(head[:4]) != streamMagic {
+		return errors.New("rangecoder: bad stream magic")
+	}
+	pos := 5
+	blockSize, ok := readU32LE(head, &pos)
+	if !ok {
+		return errors.New("rangecoder: truncated stream header")
+	}
+	r.opts = WriterOptions{Mode: Mode(head[4]), BlockSize: int(blockSize)}
+	if r.opts.Mode == ModeAdaptive {
+		r.model = newAdaptiveModel()
+	}
+	r.read = true
+	return nil
+}
+
+// Read implements io.Reader, decoding blocks on demand.
+func (r *Reader) Read(p []byte) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+	if err := r.readStreamHeader(); err != nil {
+		r.err = err
+		return 0, err
+	}
+	for len(r.pending) == 0 && !r.eof {
+		if err := r.readNextBlock(); err != nil {
+			if err == io.EOF {
+				r.eof = true
+				break
+			}
+			r.err = err
+			return 0, err
+		}
+	}
+	if len(r.pending) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, r.pending)
+	r.pending = r.pending[n:]
+	return n, nil
+}
+
+// Close releases resources held by the Reader. It does not close the
+// underlying io.Reader.
+func (r *Reader) Close() error {
+	return nil
+}
+
+func (r *Reader) readNextBlock() error {
+	magic := make([]byte, 4)
+	if _, err := io.ReadFull(r.r, magic); err != nil {
+		return io.EOF
+	}
+	switch string(magic) {
+	case endMagic:
+		return io.EOF
+	case blockMagic:
+	default:
+		return errors.New("rangecoder: bad block magic")
+	}
+
+	lenBuf := make([]byte, 4)
+	if _, err := io.ReadFull(r.r, lenBuf); err != nil {
+		return errors.New("rangecoder: truncated block header")
+	}
+	rawLen := binary.LittleEndian.Uint32(lenBuf)
+
+	var cum []uint32
+	if r.opts.Mode == ModeStatic {
+		freq, err := readFreqTableFrom(r.r)
+		if err != nil {
+			return err
+		}
+		cum = buildCumulative(freq)
+	}
+
+	clBuf := make([]byte, 4)
+	if _, err := io.ReadFull(r.r, clBuf); err != nil {
+		return errors.New("rangecoder: truncated block header")
+	}
+	compLen := binary.LittleEndian.Uint32(clBuf)
+	payload := make([]byte, compLen)
+	if _, err := io.ReadFull(r.r, payload); err != nil {
+		return errors.New("rangecoder: truncated block payload")
+	}
+
+	dec := newDecoder(payload)
+	out := make([]byte, 0, rawLen)
+	for i := uint32(0); i < rawLen; i++ {
+		var sym uint32
+		if r.opts.Mode == ModeStatic {
+			sym = dec.decodeSymbol(cum)
+		} else {
+			sym = dec.decodeSymbol(r.model.cum)
+			r.model.update(sym)
+		}
+		out = append(out, byte(sym))
+	}
+	r.pending = out
+	return nil
+}
+
+// readFreqTableFrom reads a frequency table directly from an io.Reader,
+// for use by the block decoder which cannot buffer a whole block up front.
+func readFreqTableFrom(r io.Reader) ([]uint32, error) {
+	countBuf := make([]byte, 4)
+	if _, err := io.ReadFull(r, countBuf); err != nil {
+		return nil, errors.New("rangecoder: truncated frequency table")
+	}
+	count := binary.LittleEndian.Uint32(countBuf)
+	if count == 0 || count > 1024 {
+		return nil, errors.New("rangecoder: bad frequency table size")
+	}
+	raw := make([]byte, 4*count)
+	if _, err := io.ReadFull(r, raw); err != nil {
+		return nil, errors.New("rangecoder: truncated frequency table")
+	}
+	freq := make([]uint32, count)
+	for i := range freq {
+		freq[i] = binary.LittleEndian.Uint32(raw[4*i:])
+	}
+	return freq, nil
+}
+
+// adaptiveModel is a order-0 frequency model that both Writer and Reader
+// mutate identically after every coded symbol, so no table is transmitted.
+type adaptiveModel struct {
+	freq []uint32
+	cum  []uint32
+}
+
+func newAdaptiveModel() *adaptiveModel {
+	freq := make([]uint32, symbolLimit)
+	for i := range freq {
+		freq[i] = 1
+	}
+	m := &adaptiveModel{freq: freq}
+	m.cum = buildCumulative(m.freq)
+	return m
+}
+
+func (m *adaptiveModel) update(symbol uint32) {
+	m.freq[symbol]++
+	if uint64(m.cum[len(m.cum)-1])+1 >= uint64(maxTotal) {
+		for i := range m.freq {
+			m.freq[i] = (m.freq[i] + 1) / 2
+		}
+	}
+	m.cum = buildCumulative(m.freq)
+}
@@ -0,0 +1,133 @@
+package rangecoder
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"testing"
+)
+
+func makeSeededData(n int, seed int64) []byte {
+	b := make([]byte, n)
+	r := rand.New(rand.NewSource(seed))
+	if n > 0 {
+		_, _ = r.Read(b)
+	}
+	return b
+}
+
+func streamRoundTrip(t *testing.T, mode Mode, blockSize int, data []byte) {
+	t.Helper()
+
+	var buf bytes.Buffer
+	w := NewWriterOptions(&buf, WriterOptions{Mode: mode, BlockSize: blockSize})
+	if _, err := w.Write(data); err != nil {
+		t.Fatalf("write error: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("close error: %v", err)
+	}
+
+	got, err := io.ReadAll(NewReader(&buf))
+	if err != nil {
+		t.Fatalf("read error: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatalf("mismatch: decoded %d bytes differ from the original %d bytes", len(got), len(data))
+	}
+}
+
+// TestStreamRoundTrip exercises Writer/Reader across both modelling modes,
+// a range of block sizes relative to the data length (smaller than one
+// block, exactly one block, and spanning several), and several seeds, since
+// the range coder's carry propagation is data-dependent rather than purely
+// size-dependent.
+func TestStreamRoundTrip(t *testing.T) {
+	sizes := []int{0, 1, 100, 1000, 4096, 65536}
+	blockSizes := []int{16, 256, 1024, 4096, defaultBlockSize}
+	seeds := []int64{1, 2, 3, 42}
+
+	for _, mode := range []Mode{ModeStatic, ModeAdaptive} {
+		for _, size := range sizes {
+			for _, blockSize := range blockSizes {
+				for _, seed := range seeds {
+					data := makeSeededData(size, seed)
+					streamRoundTrip(t, mode, blockSize, data)
+				}
+			}
+		}
+	}
+
+	// A handful of multi-megabyte, multi-block inputs per mode: large enough
+	// to exercise many blocks' worth of carry propagation without the full
+	// small-size matrix's combinatorial blow-up.
+	for _, mode := range []Mode{ModeStatic, ModeAdaptive} {
+		for _, seed := range []int64{1, 2, 3} {
+			data := makeSeededData(1<<20, seed)
+			streamRoundTrip(t, mode, 1024, data)
+		}
+	}
+}
+
+// TestStreamRoundTripPartialWrites checks that splitting the input across
+// many small Write calls (instead of one big one) doesn't change the
+// decoded result, since Write buffers across calls until a block fills.
+func TestStreamRoundTripPartialWrites(t *testing.T) {
+	data := makeSeededData(200000, 7)
+
+	var buf bytes.Buffer
+	w := NewWriterOptions(&buf, WriterOptions{Mode: ModeStatic, BlockSize: 4096})
+	for i := 0; i < len(data); i += 37 {
+		end := i + 37
+		if end > len(data) {
+			end = len(data)
+		}
+		if _, err := w.Write(data[i:end]); err != nil {
+			t.Fatalf("write error: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("close error: %v", err)
+	}
+
+	got, err := io.ReadAll(NewReader(&buf))
+	if err != nil {
+		t.Fatalf("read error: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatalf("mismatch: decoded data differs from the original")
+	}
+}
+
+// TestStreamRoundTripSmallReads checks that Reader.Read still reassembles
+// the original bytes correctly when called with a buffer much smaller than
+// a block, forcing Read to be called many times per block.
+func TestStreamRoundTripSmallReads(t *testing.T) {
+	data := makeSeededData(50000, 9)
+
+	var buf bytes.Buffer
+	w := NewWriterOptions(&buf, WriterOptions{Mode: ModeAdaptive, BlockSize: 8192})
+	if _, err := w.Write(data); err != nil {
+		t.Fatalf("write error: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("close error: %v", err)
+	}
+
+	rd := NewReader(&buf)
+	var out []byte
+	chunk := make([]byte, 3)
+	for {
+		n, err := rd.Read(chunk)
+		out = append(out, chunk[:n]...)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("read error: %v", err)
+		}
+	}
+	if !bytes.Equal(out, data) {
+		t.Fatalf("mismatch: decoded data differs from the original")
+	}
+}